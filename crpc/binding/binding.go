@@ -2,10 +2,68 @@ package binding
 
 import "net/http"
 
+// 常用的Content-Type
+const (
+	MIMEJSON              = "application/json"
+	MIMEXML               = "application/xml"
+	MIMEXML2              = "text/xml"
+	MIMEPOSTForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEPROTOBUF          = "application/x-protobuf"
+	MIMEMSGPACK           = "application/x-msgpack"
+	MIMEMSGPACK2          = "application/msgpack"
+	MIMEYAML              = "application/x-yaml"
+	MIMEYAML2             = "application/yaml"
+)
+
 type Binding interface {
 	Name() string
 	Bind(*http.Request, any) error
 }
 
-var JSON Binding = &jsonBinding{}
-var XML Binding = &xmlBinding{}
+// BindingBody 可以从已读取的请求体中绑定的绑定器，用于同一个请求体多次绑定
+type BindingBody interface {
+	Binding
+	BindBody([]byte, any) error
+}
+
+// BindingUri 路径参数绑定器
+type BindingUri interface {
+	Name() string
+	BindUri(map[string][]string, any) error
+}
+
+var JSON BindingBody = &jsonBinding{}
+var XML BindingBody = &xmlBinding{}
+var URI BindingUri = &uriBinding{}
+var Query Binding = queryBinding{}
+var Form Binding = formBinding{}
+var FormMultipart Binding = formMultipartBinding{}
+var Header Binding = headerBinding{}
+var Cookie Binding = cookieBinding{}
+var ProtoBuf BindingBody = protobufBinding{}
+var MsgPack BindingBody = msgpackBinding{}
+var YAML BindingBody = yamlBinding{}
+
+// Default 根据请求方法和Content-Type选择绑定器，GET请求以及未知的Content-Type使用Form
+func Default(method, contentType string) Binding {
+	if method == http.MethodGet {
+		return Form
+	}
+	switch contentType {
+	case MIMEJSON:
+		return JSON
+	case MIMEXML, MIMEXML2:
+		return XML
+	case MIMEPROTOBUF:
+		return ProtoBuf
+	case MIMEMSGPACK, MIMEMSGPACK2:
+		return MsgPack
+	case MIMEYAML, MIMEYAML2:
+		return YAML
+	case MIMEMultipartPOSTForm:
+		return FormMultipart
+	default:
+		return Form
+	}
+}
@@ -0,0 +1,66 @@
+package binding
+
+import (
+	"bytes"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	cases := []struct {
+		method, contentType string
+		want                Binding
+	}{
+		{http.MethodGet, MIMEJSON, Form},
+		{http.MethodPost, MIMEJSON, JSON},
+		{http.MethodPost, MIMEXML2, XML},
+		{http.MethodPost, MIMEPROTOBUF, ProtoBuf},
+		{http.MethodPut, MIMEMSGPACK2, MsgPack},
+		{http.MethodPatch, MIMEYAML, YAML},
+		{http.MethodPost, MIMEMultipartPOSTForm, FormMultipart},
+		{http.MethodPost, MIMEPOSTForm, Form},
+		{http.MethodPost, "", Form},
+	}
+	for _, c := range cases {
+		if got := Default(c.method, c.contentType); got != c.want {
+			t.Errorf("Default(%s, %q) = %s, want %s", c.method, c.contentType, got.Name(), c.want.Name())
+		}
+	}
+}
+
+func TestProtoBufBinding(t *testing.T) {
+	body, err := proto.Marshal(wrapperspb.String("crpc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	var message wrapperspb.StringValue
+	if err := ProtoBuf.Bind(req, &message); err != nil || message.GetValue() != "crpc" {
+		t.Fatalf("got %q, %v", message.GetValue(), err)
+	}
+	if err := ProtoBuf.BindBody(body, &struct{}{}); err == nil {
+		t.Fatal("expected error for non proto.Message")
+	}
+}
+
+func TestMsgPackBinding(t *testing.T) {
+	type goods struct {
+		Name  string `msgpack:"name" validate:"required"`
+		Price int    `msgpack:"price"`
+	}
+	body, err := msgpack.Marshal(map[string]any{"name": "phone", "price": 999})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g goods
+	if err := MsgPack.Bind(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)), &g); err != nil {
+		t.Fatal(err)
+	}
+	if g.Name != "phone" || g.Price != 999 {
+		t.Fatalf("unexpected goods %+v", g)
+	}
+}
@@ -0,0 +1,90 @@
+package binding
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"strings"
+)
+
+// JSON中存在结构体没有的字段时使用的规则名
+const unknownRule = "unknown"
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`           // 字段路径，使用json标签，如items[0].name
+	Rule    string `json:"rule"`            // 违反的规则，如required、min
+	Param   string `json:"param,omitempty"` // 规则参数，如min=3中的3
+	Message string `json:"message"`         // 错误信息
+
+	fe validator.FieldError
+}
+
+// ValidationErrors 参数校验失败的字段列表
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldError := range e {
+		messages[i] = fieldError.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Translate 返回使用指定语言的错误信息的副本，如zh、en、zh-CN
+func (e ValidationErrors) Translate(locale string) ValidationErrors {
+	trans := translator(locale)
+	translated := make(ValidationErrors, len(e))
+	for i, fieldError := range e {
+		if fieldError.fe != nil {
+			fieldError.Message = fieldError.fe.Translate(trans)
+		} else if text, err := trans.T(fieldError.Rule, fieldError.Field, fieldError.Param); err == nil {
+			fieldError.Message = text
+		} else {
+			fieldError.Message = fieldError.Field + ": " + fieldError.Rule
+		}
+		translated[i] = fieldError
+	}
+	return translated
+}
+
+func newFieldError(fe validator.FieldError, prefix string) FieldError {
+	// 去掉命名空间中的结构体名，如User.items[0].name变为items[0].name
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+	if prefix != "" {
+		field = prefix + "." + field
+	}
+	return FieldError{
+		Field:   field,
+		Rule:    fe.Tag(),
+		Param:   fe.Param(),
+		Message: fe.Error(),
+		fe:      fe,
+	}
+}
+
+// FieldErrors 将绑定返回的错误转换为使用指定语言的字段错误列表，
+// 支持参数验证错误和JSON中缺少必填字段、存在未知字段的错误，其他错误返回false
+func FieldErrors(err error, locale string) (ValidationErrors, bool) {
+	var validationErrors ValidationErrors
+	if errors.As(err, &validationErrors) {
+		return validationErrors.Translate(locale), true
+	}
+	var fieldsErr *JSONFieldsError
+	if errors.As(err, &fieldsErr) {
+		for _, field := range fieldsErr.Missing {
+			validationErrors = append(validationErrors, FieldError{Field: field, Rule: "required"})
+		}
+		for _, field := range fieldsErr.Unknown {
+			validationErrors = append(validationErrors, FieldError{Field: field, Rule: unknownRule})
+		}
+		return validationErrors.Translate(locale), true
+	}
+	var rawErrors validator.ValidationErrors
+	if errors.As(err, &rawErrors) {
+		for _, fe := range rawErrors {
+			validationErrors = append(validationErrors, newFieldError(fe, ""))
+		}
+		return validationErrors.Translate(locale), true
+	}
+	return nil, false
+}
@@ -0,0 +1,46 @@
+package binding
+
+import (
+	"errors"
+	"net/http"
+)
+
+// 解析multipart表单时保存在内存中的最大字节数，超出部分保存在临时文件中
+const defaultMemory = 32 << 20
+
+type formBinding struct {
+}
+
+func (f formBinding) Name() string {
+	return "form"
+}
+
+// Bind 根据form标签将查询参数和请求体中的表单（包括multipart表单）绑定到结构体中
+func (f formBinding) Bind(request *http.Request, model any) error {
+	if err := request.ParseMultipartForm(defaultMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	if err := mapFormByTag(model, request.Form, "form"); err != nil {
+		return err
+	}
+	return validate(model)
+}
+
+type formMultipartBinding struct {
+}
+
+func (f formMultipartBinding) Name() string {
+	return "multipart/form-data"
+}
+
+// Bind 根据form标签绑定multipart表单，*multipart.FileHeader和[]*multipart.FileHeader类型的字段接收上传的文件
+func (f formMultipartBinding) Bind(request *http.Request, model any) error {
+	if err := request.ParseMultipartForm(defaultMemory); err != nil {
+		return err
+	}
+	m := &formMapping{form: request.Form, files: request.MultipartForm.File, tag: "form"}
+	if err := mapForm(model, m); err != nil {
+		return err
+	}
+	return validate(model)
+}
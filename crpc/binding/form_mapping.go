@@ -0,0 +1,297 @@
+package binding
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	fileHeaderType = reflect.TypeOf(&multipart.FileHeader{})
+)
+
+// 一次绑定使用的数据源
+type formMapping struct {
+	form  map[string][]string
+	files map[string][]*multipart.FileHeader // multipart表单中的文件，只有FormMultipart绑定时存在
+	tag   string
+}
+
+// 根据tag将键值对映射到结构体字段中
+func mapFormByTag(model any, form map[string][]string, tag string) error {
+	return mapForm(model, &formMapping{form: form, tag: tag})
+}
+
+func mapForm(model any, m *formMapping) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("this model is not a pointer")
+	}
+	v = v.Elem()
+	if v.Kind() != reflect.Struct {
+		return errors.New("this model is not a struct pointer")
+	}
+	return m.mapStruct(v)
+}
+
+// 遍历结构体字段，匿名字段和无tag的结构体字段会递归处理；
+// 字段没有对应的值时使用default标签中的默认值，切片的默认值以逗号分隔
+func (m *formMapping) mapStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name := field.Tag.Get(m.tag)
+		if name == "-" {
+			continue
+		}
+		fv := v.Field(i)
+		if name == "" {
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				if err := m.mapStruct(fv); err != nil {
+					return err
+				}
+				continue
+			}
+			name = field.Name
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if m.tag == "header" {
+			name = textproto.CanonicalMIMEHeaderKey(name)
+		}
+		if err := m.mapField(fv, field, name); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// 为单个字段赋值
+func (m *formMapping) mapField(fv reflect.Value, field reflect.StructField, name string) error {
+	if isFileField(fv.Type()) {
+		return setFiles(fv, m.files[name])
+	}
+	if fv.Kind() == reflect.Map {
+		if ok, err := m.setMap(fv, field, name); ok || err != nil {
+			return err
+		}
+	}
+	values, ok := m.form[name]
+	if !ok || len(values) == 0 {
+		def, ok := field.Tag.Lookup("default")
+		if !ok {
+			return nil
+		}
+		values = []string{def}
+		if kind := indirectType(fv.Type()).Kind(); kind == reflect.Slice || kind == reflect.Array {
+			values = strings.Split(def, ",")
+		}
+	}
+	return setField(fv, values, field)
+}
+
+// 将name[key]形式的键值对映射到map字段中，如user[name]=ceer&user[age]=18；
+// 不存在这种形式的键时返回false
+func (m *formMapping) setMap(fv reflect.Value, field reflect.StructField, name string) (bool, error) {
+	t := fv.Type()
+	if t.Key().Kind() != reflect.String {
+		return false, fmt.Errorf("unsupported map key type %s", t.Key())
+	}
+	dict := reflect.MakeMap(t)
+	for k, values := range m.form {
+		if len(values) == 0 || !strings.HasPrefix(k, name+"[") || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := setField(elem, values, field); err != nil {
+			return true, err
+		}
+		dict.SetMapIndex(reflect.ValueOf(k[len(name)+1:len(k)-1]).Convert(t.Key()), elem)
+	}
+	if dict.Len() == 0 {
+		return false, nil
+	}
+	fv.Set(dict)
+	return true, nil
+}
+
+// 为字段赋值，切片字段接收全部值，其他字段取第一个值
+func setField(fv reflect.Value, values []string, field reflect.StructField) error {
+	switch fv.Kind() {
+	case reflect.Pointer:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setField(fv.Elem(), values, field)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value, field); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	case reflect.Array:
+		if len(values) != fv.Len() {
+			return fmt.Errorf("%q is not valid value for %s", values, fv.Type())
+		}
+		for i, value := range values {
+			if err := setValue(fv.Index(i), value, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return setValue(fv, values[0], field)
+	}
+}
+
+// 将字符串转换为字段对应的类型
+func setValue(fv reflect.Value, value string, field reflect.StructField) error {
+	switch fv.Type() {
+	case timeType:
+		return setTime(fv, value, field)
+	case durationType:
+		if value == "" {
+			value = "0"
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.Pointer:
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return setValue(fv.Elem(), value, field)
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		if value == "" {
+			value = "false"
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value == "" {
+			value = "0"
+		}
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value == "" {
+			value = "0"
+		}
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if value == "" {
+			value = "0"
+		}
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// 解析时间，time_format标签指定格式，默认为RFC3339，也可以是unix、unixmilli、unixnano时间戳；
+// time_location标签指定时区，如Asia/Shanghai，默认为本地时区，time_utc:"1"表示UTC
+func setTime(fv reflect.Value, value string, field reflect.StructField) error {
+	if value == "" {
+		fv.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+	format := field.Tag.Get("time_format")
+	switch format {
+	case "unix", "unixmilli", "unixnano":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		var t time.Time
+		switch format {
+		case "unix":
+			t = time.Unix(n, 0)
+		case "unixmilli":
+			t = time.UnixMilli(n)
+		default:
+			t = time.Unix(0, n)
+		}
+		fv.Set(reflect.ValueOf(t))
+		return nil
+	case "":
+		format = time.RFC3339
+	}
+	location := time.Local
+	if utc, _ := strconv.ParseBool(field.Tag.Get("time_utc")); utc {
+		location = time.UTC
+	}
+	if name := field.Tag.Get("time_location"); name != "" {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return err
+		}
+		location = loc
+	}
+	t, err := time.ParseInLocation(format, value, location)
+	if err != nil {
+		return err
+	}
+	fv.Set(reflect.ValueOf(t))
+	return nil
+}
+
+// 字段是否为*multipart.FileHeader或其切片
+func isFileField(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+func setFiles(fv reflect.Value, files []*multipart.FileHeader) error {
+	if len(files) == 0 {
+		return nil
+	}
+	if fv.Kind() == reflect.Slice {
+		fv.Set(reflect.ValueOf(files).Convert(fv.Type()))
+		return nil
+	}
+	fv.Set(reflect.ValueOf(files[0]))
+	return nil
+}
+
+// 去掉指针后的类型
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,135 @@
+package binding
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pageQuery struct {
+	Page     int               `form:"page" default:"1"`
+	Size     *int              `form:"size" default:"20"`
+	Tags     []string          `form:"tag"`
+	Status   []int             `form:"status" default:"1,2"`
+	Since    time.Time         `form:"since" time_format:"2006-01-02" time_utc:"1"`
+	Until    time.Time         `form:"until" time_format:"unix"`
+	Timeout  time.Duration     `form:"timeout"`
+	User     map[string]string `form:"user"`
+	Score    map[string]int    `form:"score"`
+	Keyword  string            `form:"q" validate:"required"`
+	Internal string            `form:"-"`
+}
+
+func TestQueryBinding(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet,
+		"/goods?q=phone&tag=a&tag=b&since=2023-05-01&until=1700000000&timeout=1m30s&user[name]=ceer&user[age]=18&score[math]=90&Internal=x", nil)
+	var query pageQuery
+	if err := Query.Bind(req, &query); err != nil {
+		t.Fatal(err)
+	}
+	if query.Page != 1 || query.Size == nil || *query.Size != 20 || len(query.Status) != 2 || query.Status[1] != 2 {
+		t.Fatalf("defaults not applied: %+v", query)
+	}
+	if query.Keyword != "phone" || strings.Join(query.Tags, ",") != "a,b" || query.Internal != "" {
+		t.Fatalf("unexpected values: %+v", query)
+	}
+	if !query.Since.Equal(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)) || query.Until.Unix() != 1700000000 {
+		t.Fatalf("unexpected time: %v %v", query.Since, query.Until)
+	}
+	if query.Timeout != 90*time.Second {
+		t.Fatalf("unexpected duration %v", query.Timeout)
+	}
+	if query.User["name"] != "ceer" || query.User["age"] != "18" || query.Score["math"] != 90 {
+		t.Fatalf("unexpected map: %v %v", query.User, query.Score)
+	}
+
+	// 校验失败
+	req = httptest.NewRequest(http.MethodGet, "/goods?page=2", nil)
+	if err := Query.Bind(req, &pageQuery{}); err == nil {
+		t.Fatal("expected validation error")
+	}
+	// 类型错误
+	req = httptest.NewRequest(http.MethodGet, "/goods?q=x&page=abc", nil)
+	if err := Query.Bind(req, &pageQuery{}); err == nil || !strings.Contains(err.Error(), "page") {
+		t.Fatalf("expected field error, got %v", err)
+	}
+}
+
+func TestFormBinding(t *testing.T) {
+	type login struct {
+		Name     string `form:"name"`
+		Password string `form:"password"`
+		Remember bool   `form:"remember"`
+	}
+	form := url.Values{"name": {"ceer"}, "password": {"123"}, "remember": {"true"}}
+	req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var l login
+	if err := Form.Bind(req, &l); err != nil {
+		t.Fatal(err)
+	}
+	if l.Name != "ceer" || l.Password != "123" || !l.Remember {
+		t.Fatalf("unexpected form %+v", l)
+	}
+}
+
+func TestFormMultipartBinding(t *testing.T) {
+	type upload struct {
+		Title  string                  `form:"title"`
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Photos []*multipart.FileHeader `form:"photo"`
+	}
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	_ = writer.WriteField("title", "hello")
+	for _, name := range []string{"avatar", "photo", "photo"} {
+		part, _ := writer.CreateFormFile(name, name+".txt")
+		_, _ = part.Write([]byte(name))
+	}
+	_ = writer.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	var u upload
+	if err := FormMultipart.Bind(req, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Title != "hello" || u.Avatar == nil || u.Avatar.Filename != "avatar.txt" || len(u.Photos) != 2 {
+		t.Fatalf("unexpected upload %+v", u)
+	}
+}
+
+func TestHeaderAndCookieBinding(t *testing.T) {
+	type meta struct {
+		Token    string   `header:"x-token"`
+		Accept   []string `header:"Accept"`
+		Language string   `header:"Accept-Language" default:"zh-CN"`
+	}
+	type session struct {
+		ID    string `cookie:"session_id"`
+		Theme string `cookie:"theme" default:"light"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Token", "abc")
+	req.Header.Add("Accept", "text/html")
+	req.Header.Add("Accept", "application/json")
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "s1"})
+	var m meta
+	if err := Header.Bind(req, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Token != "abc" || len(m.Accept) != 2 || m.Language != "zh-CN" {
+		t.Fatalf("unexpected header %+v", m)
+	}
+	var s session
+	if err := Cookie.Bind(req, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != "s1" || s.Theme != "light" {
+		t.Fatalf("unexpected cookie %+v", s)
+	}
+}
@@ -0,0 +1,37 @@
+package binding
+
+import "net/http"
+
+type headerBinding struct {
+}
+
+func (h headerBinding) Name() string {
+	return "header"
+}
+
+// Bind 根据header标签将请求头绑定到结构体中，请求头名称不区分大小写
+func (h headerBinding) Bind(request *http.Request, model any) error {
+	if err := mapFormByTag(model, request.Header, "header"); err != nil {
+		return err
+	}
+	return validate(model)
+}
+
+type cookieBinding struct {
+}
+
+func (c cookieBinding) Name() string {
+	return "cookie"
+}
+
+// Bind 根据cookie标签将Cookie绑定到结构体中
+func (c cookieBinding) Bind(request *http.Request, model any) error {
+	cookies := make(map[string][]string)
+	for _, cookie := range request.Cookies() {
+		cookies[cookie.Name] = append(cookies[cookie.Name], cookie.Value)
+	}
+	if err := mapFormByTag(model, cookies, "cookie"); err != nil {
+		return err
+	}
+	return validate(model)
+}
@@ -1,13 +1,28 @@
 package binding
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+// JSONOptions JSON绑定选项
+type JSONOptions struct {
+	DisallowUnknownFields bool // 请求中存在结构体没有的字段时返回错误
+	CheckRequired         bool // 检查带有crpc:"require"标签的字段是否存在，包括嵌套结构体和匿名字段
+}
+
+// NewJSON 使用指定选项创建JSON绑定器，Context.DisallowUnknownFields和Context.IsValidate通过它生效
+func NewJSON(opts JSONOptions) BindingBody {
+	return &jsonBinding{disallowUnknownFields: opts.DisallowUnknownFields, isValidate: opts.CheckRequired}
+}
+
 type jsonBinding struct {
 	disallowUnknownFields bool
 	isValidate            bool
@@ -22,79 +37,158 @@ func (j *jsonBinding) Bind(request *http.Request, model any) error {
 	if body == nil {
 		return errors.New("invalid request")
 	}
-	decoder := json.NewDecoder(body)
-	if j.disallowUnknownFields {
-		decoder.DisallowUnknownFields()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
 	}
+	return j.BindBody(data, model)
+}
 
-	if j.isValidate {
-		err := validateParam(model, decoder)
-		if err != nil {
-			return err
+// BindBody 从已读取的请求体中绑定
+func (j *jsonBinding) BindBody(body []byte, model any) error {
+	empty := len(bytes.TrimSpace(body)) == 0
+	if j.isValidate || j.disallowUnknownFields {
+		// 请求体为空时按空对象检查必填字段
+		var value any = map[string]any{}
+		if !empty {
+			if err := json.Unmarshal(body, &value); err != nil {
+				return err
+			}
+		}
+		fieldsErr := &JSONFieldsError{}
+		j.checkFields(reflect.TypeOf(model), value, "", fieldsErr)
+		if len(fieldsErr.Missing) > 0 || len(fieldsErr.Unknown) > 0 {
+			return fieldsErr
 		}
 	}
-	err := decoder.Decode(model)
-	if err != nil && err != io.EOF {
+	if empty {
+		return validate(model)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	if j.disallowUnknownFields {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(model); err != nil {
 		return err
 	}
 	return validate(model)
 }
 
-// 验证数组、切片
-func checkParamSlice(of reflect.Type, model any, decoder *json.Decoder) error {
-	mv := make([]map[string]interface{}, 0)
-	err := decoder.Decode(&mv)
-	if err != nil {
-		return err
+// JSONFieldsError JSON中缺少的必填字段和未知字段，字段路径使用json标签，如items[0].name
+type JSONFieldsError struct {
+	Missing []string
+	Unknown []string
+}
+
+func (e *JSONFieldsError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "miss field "+strings.Join(e.Missing, ", "))
 	}
-	for i := 0; i < of.NumField(); i++ {
-		jsonTag := of.Field(i).Tag.Get("json")
-		if jsonTag == "" {
-			jsonTag = of.Field(i).Name
-		}
-		for _, v := range mv {
-			if _, ok := v[jsonTag]; !ok && of.Field(i).Tag.Get("crpc") == "require" {
-				return errors.New("miss field " + jsonTag)
-			}
-		}
+	if len(e.Unknown) > 0 {
+		parts = append(parts, "unknown field "+strings.Join(e.Unknown, ", "))
 	}
-	marshal, err := json.Marshal(mv)
-	if err != nil {
-		return err
+	return strings.Join(parts, "; ")
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// 对照模型的类型检查JSON中的字段，切片、数组和map中的元素以及嵌套结构体会递归检查
+func (j *jsonBinding) checkFields(t reflect.Type, value any, path string, fieldsErr *JSONFieldsError) {
+	t = indirectType(t)
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return
 	}
-	err = json.Unmarshal(marshal, model)
-	if err != nil {
-		return err
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		known := make(map[string]bool)
+		j.checkStruct(t, object, path, known, fieldsErr)
+		if !j.disallowUnknownFields {
+			return
+		}
+		var unknown []string
+		for key := range object {
+			if !known[strings.ToLower(key)] {
+				unknown = append(unknown, joinPath(path, key))
+			}
+		}
+		sort.Strings(unknown)
+		fieldsErr.Unknown = append(fieldsErr.Unknown, unknown...)
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for i, item := range items {
+			j.checkFields(t.Elem(), item, path+"["+strconv.Itoa(i)+"]", fieldsErr)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			j.checkFields(t.Elem(), object[key], joinPath(path, key), fieldsErr)
+		}
 	}
-	return nil
 }
 
-// 验证普通结构体
-func checkParam(of reflect.Value, model any, decoder *json.Decoder) error {
-	mv := make(map[string]any)
-	err := decoder.Decode(&mv)
-	if err != nil {
-		return err
-	}
-	for i := 0; i < of.NumField(); i++ {
-		jsonTag := of.Type().Field(i).Tag.Get("json")
-		if jsonTag == "" {
-			jsonTag = of.Type().Field(i).Name
+// 检查结构体字段，没有json标签的匿名结构体字段与外层字段位于同一层级
+func (j *jsonBinding) checkStruct(t reflect.Type, object map[string]any, path string, known map[string]bool, fieldsErr *JSONFieldsError) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" && tag == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			j.checkStruct(indirectType(field.Type), object, path, known, fieldsErr)
+			continue
 		}
-		if _, ok := mv[jsonTag]; !ok && of.Type().Field(i).Tag.Get("crpc") == "require" {
-			return errors.New("miss field " + jsonTag)
+		if !field.IsExported() {
+			continue
 		}
+		if name == "" {
+			name = field.Name
+		}
+		known[strings.ToLower(name)] = true
+		value, ok := lookupField(object, name)
+		if !ok {
+			if j.isValidate && field.Tag.Get("crpc") == "require" {
+				fieldsErr.Missing = append(fieldsErr.Missing, joinPath(path, name))
+			}
+			continue
+		}
+		j.checkFields(field.Type, value, joinPath(path, name), fieldsErr)
 	}
-	if err != nil && err != io.EOF {
-		return err
+}
+
+// 查找字段，与encoding/json一致，精确匹配失败时不区分大小写
+func lookupField(object map[string]any, name string) (any, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
 	}
-	marshal, err := json.Marshal(mv)
-	if err != nil {
-		return err
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
 	}
-	err = json.Unmarshal(marshal, model)
-	if err != nil {
-		return err
+	return nil, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
 	}
-	return nil
+	return path + "." + name
 }
@@ -0,0 +1,61 @@
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type jsonBase struct {
+	ID int64 `json:"id" crpc:"require"`
+}
+
+type jsonItem struct {
+	Name  string `json:"name" crpc:"require"`
+	Count int    `json:"count"`
+}
+
+type jsonOrder struct {
+	jsonBase
+	User  string     `json:"user" crpc:"require"`
+	Items []jsonItem `json:"items"`
+	Extra *jsonItem  `json:"extra,omitempty"`
+	Skip  string     `json:"-"`
+}
+
+func TestJSONBinding(t *testing.T) {
+	bind := func(opts JSONOptions, body string) (*jsonOrder, error) {
+		order := &jsonOrder{}
+		err := NewJSON(opts).Bind(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), order)
+		return order, err
+	}
+
+	body := `{"id":1,"user":"ceer","items":[{"name":"a","count":2}],"extra":{"name":"b"}}`
+	order, err := bind(JSONOptions{DisallowUnknownFields: true, CheckRequired: true}, body)
+	if err != nil || order.ID != 1 || order.Items[0].Count != 2 || order.Extra.Name != "b" {
+		t.Fatalf("unexpected order %+v, %v", order, err)
+	}
+
+	// 缺少的字段和未知字段一次性全部返回
+	body = `{"USER":"ceer","items":[{"count":1},{"name":"b","price":3}],"extra":{},"coupon":"x"}`
+	_, err = bind(JSONOptions{DisallowUnknownFields: true, CheckRequired: true}, body)
+	fieldsErr, ok := err.(*JSONFieldsError)
+	if !ok {
+		t.Fatalf("expected JSONFieldsError, got %v", err)
+	}
+	if strings.Join(fieldsErr.Missing, ",") != "id,items[0].name,extra.name" {
+		t.Errorf("unexpected missing fields %v", fieldsErr.Missing)
+	}
+	if strings.Join(fieldsErr.Unknown, ",") != "items[1].price,coupon" {
+		t.Errorf("unexpected unknown fields %v", fieldsErr.Unknown)
+	}
+
+	// 选项关闭时不检查
+	if _, err = bind(JSONOptions{}, body); err != nil {
+		t.Fatalf("default binding should ignore missing and unknown fields: %v", err)
+	}
+	if _, err = bind(JSONOptions{CheckRequired: true}, ""); err == nil || !strings.Contains(err.Error(), "miss field id, user") {
+		t.Fatalf("empty body should report missing fields, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"io"
+	"net/http"
+)
+
+type msgpackBinding struct {
+}
+
+func (m msgpackBinding) Name() string {
+	return "msgpack"
+}
+
+// Bind 将MessagePack请求体反序列化到结构体中，字段名使用msgpack标签
+func (m msgpackBinding) Bind(request *http.Request, model any) error {
+	if request.Body == nil {
+		return errors.New("request Body is nil")
+	}
+	return m.decode(request.Body, model)
+}
+
+// BindBody 从已读取的请求体中绑定
+func (m msgpackBinding) BindBody(body []byte, model any) error {
+	return m.decode(bytes.NewReader(body), model)
+}
+
+func (m msgpackBinding) decode(body io.Reader, model any) error {
+	if err := msgpack.NewDecoder(body).Decode(model); err != nil && err != io.EOF {
+		return err
+	}
+	return validate(model)
+}
@@ -0,0 +1,39 @@
+package binding
+
+import (
+	"errors"
+	"google.golang.org/protobuf/proto"
+	"io"
+	"net/http"
+)
+
+type protobufBinding struct {
+}
+
+func (p protobufBinding) Name() string {
+	return "protobuf"
+}
+
+// Bind 将Protobuf请求体反序列化到proto.Message中
+func (p protobufBinding) Bind(request *http.Request, model any) error {
+	if request.Body == nil {
+		return errors.New("request Body is nil")
+	}
+	body, err := io.ReadAll(request.Body)
+	if err != nil {
+		return err
+	}
+	return p.BindBody(body, model)
+}
+
+// BindBody 从已读取的请求体中绑定
+func (p protobufBinding) BindBody(body []byte, model any) error {
+	message, ok := model.(proto.Message)
+	if !ok {
+		return errors.New("this model does not implement proto.Message")
+	}
+	if err := proto.Unmarshal(body, message); err != nil {
+		return err
+	}
+	return validate(model)
+}
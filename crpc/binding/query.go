@@ -0,0 +1,18 @@
+package binding
+
+import "net/http"
+
+type queryBinding struct {
+}
+
+func (q queryBinding) Name() string {
+	return "query"
+}
+
+// Bind 根据form标签将查询参数绑定到结构体中
+func (q queryBinding) Bind(request *http.Request, model any) error {
+	if err := mapFormByTag(model, request.URL.Query(), "form"); err != nil {
+		return err
+	}
+	return validate(model)
+}
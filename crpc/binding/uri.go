@@ -0,0 +1,16 @@
+package binding
+
+type uriBinding struct {
+}
+
+func (u *uriBinding) Name() string {
+	return "uri"
+}
+
+// BindUri 根据uri标签将路径参数绑定到结构体中
+func (u *uriBinding) BindUri(params map[string][]string, model any) error {
+	if err := mapFormByTag(model, params, "uri"); err != nil {
+		return err
+	}
+	return validate(model)
+}
@@ -1,12 +1,16 @@
 package binding
 
 import (
-	"encoding/json"
 	"errors"
-	"fmt"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
-	crpcError "github/CeerDecy/RpcFrameWork/crpc/error"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -15,11 +19,56 @@ type StructValidator interface {
 	Engine() any
 }
 
-var Validator StructValidator = &defaultValidator{}
+// TranslatableValidator 支持自定义规则和错误信息翻译的验证器，是StructValidator的可选扩展，
+// Validator没有实现它时使用默认验证器的翻译器
+type TranslatableValidator interface {
+	StructValidator
+	// RegisterValidation 注册自定义校验规则，messages为各语言的错误信息，如{"zh": "{0}必须是手机号"}，{0}为字段名、{1}为参数
+	RegisterValidation(tag string, fn validator.Func, messages map[string]string) error
+	// RegisterAlias 注册校验规则别名，如RegisterAlias("phone", "numeric,len=11", nil)
+	RegisterAlias(alias, tags string, messages map[string]string) error
+	// Translator 获取指定语言的翻译器，不支持的语言返回DefaultLocale的翻译器
+	Translator(locale string) ut.Translator
+}
+
+var defaultStructValidator = &defaultValidator{}
+
+var Validator StructValidator = defaultStructValidator
+
+// RegisterValidation 为Validator注册自定义校验规则及其错误信息，Validator需要实现TranslatableValidator
+func RegisterValidation(tag string, fn validator.Func, messages map[string]string) error {
+	v, ok := Validator.(TranslatableValidator)
+	if !ok {
+		return errors.New("validator does not support custom validation")
+	}
+	return v.RegisterValidation(tag, fn, messages)
+}
+
+// RegisterAlias 为Validator注册校验规则别名及其错误信息，Validator需要实现TranslatableValidator
+func RegisterAlias(alias, tags string, messages map[string]string) error {
+	v, ok := Validator.(TranslatableValidator)
+	if !ok {
+		return errors.New("validator does not support custom validation")
+	}
+	return v.RegisterAlias(alias, tags, messages)
+}
+
+// 获取Validator的翻译器，Validator没有实现TranslatableValidator时使用默认验证器的翻译器
+func translator(locale string) ut.Translator {
+	if v, ok := Validator.(TranslatableValidator); ok {
+		return v.Translator(locale)
+	}
+	return defaultStructValidator.Translator(locale)
+}
+
+// DefaultLocale 默认的错误信息语言，支持zh和en
+var DefaultLocale = "zh"
 
 type defaultValidator struct {
 	validate *validator.Validate
+	uni      *ut.UniversalTranslator
 	once     sync.Once
+	initErr  error
 }
 
 func (d *defaultValidator) ValidateStruct(model any) error {
@@ -28,19 +77,26 @@ func (d *defaultValidator) ValidateStruct(model any) error {
 	case reflect.Pointer:
 		return d.ValidateStruct(of.Elem().Interface())
 	case reflect.Struct:
-		return d.validateStruct(model)
+		return d.validateStruct(model, "")
 	case reflect.Slice, reflect.Array:
-		count := of.Len()
-		sliceValidateError := make(crpcError.SliceValidateError, 0)
-		for i := 0; i < count; i++ {
-			if err := d.validateStruct(of.Index(i).Interface()); err != nil {
-				sliceValidateError = append(sliceValidateError, err)
+		var fieldErrors ValidationErrors
+		for i := 0; i < of.Len(); i++ {
+			item := of.Index(i)
+			if indirectType(item.Type()).Kind() != reflect.Struct || (item.Kind() == reflect.Pointer && item.IsNil()) {
+				continue
+			}
+			err := d.validateStruct(item.Interface(), "["+strconv.Itoa(i)+"]")
+			var itemErrors ValidationErrors
+			if errors.As(err, &itemErrors) {
+				fieldErrors = append(fieldErrors, itemErrors...)
+			} else if err != nil {
+				return err
 			}
 		}
-		if len(sliceValidateError) == 0 {
+		if len(fieldErrors) == 0 {
 			return nil
 		}
-		return sliceValidateError
+		return fieldErrors
 	}
 	return nil
 }
@@ -51,48 +107,112 @@ func (d *defaultValidator) Engine() any {
 	return d.validate
 }
 
-// 验证结构体
-func (d *defaultValidator) validateStruct(model any) error {
+func (d *defaultValidator) RegisterValidation(tag string, fn validator.Func, messages map[string]string) error {
 	d.lazyInit()
-	return d.validate.Struct(model)
+	if err := d.validate.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+	return d.registerMessages(tag, messages)
 }
 
-func (d *defaultValidator) lazyInit() {
-	d.once.Do(func() {
-		d.validate = validator.New()
-	})
+func (d *defaultValidator) RegisterAlias(alias, tags string, messages map[string]string) error {
+	d.lazyInit()
+	d.validate.RegisterAlias(alias, tags)
+	return d.registerMessages(alias, messages)
 }
 
-func validate(model any) error {
-	return Validator.ValidateStruct(model)
+// Translator locale可以是zh、zh-CN，也可以是Accept-Language请求头，如zh-CN,zh;q=0.9,en;q=0.8，按顺序使用第一个支持的语言
+func (d *defaultValidator) Translator(locale string) ut.Translator {
+	d.lazyInit()
+	for _, part := range strings.Split(locale, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "-", "_"))
+		language, _, _ := strings.Cut(tag, "_")
+		if trans, ok := d.uni.FindTranslator(tag, language); ok {
+			return trans
+		}
+	}
+	trans, _ := d.uni.GetTranslator(DefaultLocale)
+	return trans
 }
 
-// 结构体参数完整性校验
-// 反射
-func validateParam(model any, decoder *json.Decoder) error {
-	m := reflect.ValueOf(model)
-	if m.Kind() != reflect.Pointer {
-		return errors.New("this model is not a pointer")
-	}
-	elem := m.Elem().Interface()
-	of := reflect.ValueOf(elem)
-	switch of.Kind() {
-	case reflect.Struct:
-		return checkParam(of, model, decoder)
-	case reflect.Slice, reflect.Array:
-		ele := of.Type().Elem()
-		fmt.Println(ele.Kind(), reflect.Struct)
-		if ele.Kind() == reflect.Struct {
-			return checkParamSlice(ele, model, decoder)
-		} else if ele.Kind() == reflect.Pointer {
-			fmt.Println(reflect.ValueOf(ele.Elem()), ele.Elem())
-			return checkParam(reflect.ValueOf(ele.Elem()), model, decoder)
+// 为规则注册各语言的错误信息
+func (d *defaultValidator) registerMessages(tag string, messages map[string]string) error {
+	for locale, message := range messages {
+		trans, ok := d.uni.GetTranslator(locale)
+		if !ok {
+			return errors.New("unsupported locale " + locale)
 		}
-	default:
-		err := decoder.Decode(model)
+		message := message
+		err := d.validate.RegisterTranslation(tag, trans, func(trans ut.Translator) error {
+			return trans.Add(tag, message, true)
+		}, func(trans ut.Translator, fe validator.FieldError) string {
+			text, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return text
+		})
 		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// 验证结构体，将validator.ValidationErrors转换为ValidationErrors，prefix为切片元素的下标
+func (d *defaultValidator) validateStruct(model any, prefix string) error {
+	d.lazyInit()
+	if d.initErr != nil {
+		return d.initErr
+	}
+	err := d.validate.Struct(model)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	fieldErrors := make(ValidationErrors, len(validationErrors))
+	for i, fe := range validationErrors {
+		fieldErrors[i] = newFieldError(fe, prefix)
+	}
+	return fieldErrors.Translate(DefaultLocale)
+}
+
+func (d *defaultValidator) lazyInit() {
+	d.once.Do(func() {
+		d.validate = validator.New()
+		// 错误中的字段名使用json标签，没有json标签时使用form标签
+		d.validate.RegisterTagNameFunc(fieldName)
+		zhLocale := zh.New()
+		d.uni = ut.New(zhLocale, zhLocale, en.New())
+		zhTrans, _ := d.uni.GetTranslator("zh")
+		enTrans, _ := d.uni.GetTranslator("en")
+		if err := zhTranslations.RegisterDefaultTranslations(d.validate, zhTrans); err != nil {
+			d.initErr = err
+			return
+		}
+		if err := enTranslations.RegisterDefaultTranslations(d.validate, enTrans); err != nil {
+			d.initErr = err
+			return
+		}
+		d.initErr = d.registerMessages(unknownRule, map[string]string{
+			"zh": "{0}是未知字段",
+			"en": "{0} is not allowed",
+		})
+	})
+}
+
+// 字段在错误信息中的名称
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func validate(model any) error {
+	return Validator.ValidateStruct(model)
+}
@@ -0,0 +1,101 @@
+package binding
+
+import (
+	"errors"
+	"github.com/go-playground/validator/v10"
+	"strings"
+	"testing"
+)
+
+type validateItem struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type validateOrder struct {
+	User  string         `json:"user" validate:"required"`
+	Count int            `form:"count" validate:"min=1"`
+	Phone string         `json:"phone" validate:"omitempty,mobile"`
+	Code  string         `json:"code" validate:"omitempty,order_code"`
+	Items []validateItem `json:"items" validate:"dive"`
+}
+
+func TestValidationErrors(t *testing.T) {
+	err := RegisterValidation("mobile", func(fl validator.FieldLevel) bool {
+		return len(fl.Field().String()) == 11 && strings.HasPrefix(fl.Field().String(), "1")
+	}, map[string]string{"zh": "{0}必须是手机号", "en": "{0} must be a mobile number"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = RegisterAlias("order_code", "len=8,alphanum", map[string]string{"zh": "{0}必须是8位订单号"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err = validate(&validateOrder{Phone: "123", Code: "x", Items: []validateItem{{Name: "a"}, {}}})
+	var fieldErrors ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		t.Fatalf("expected ValidationErrors, got %T %v", err, err)
+	}
+	want := []FieldError{
+		{Field: "user", Rule: "required", Message: "user为必填字段"},
+		{Field: "count", Rule: "min", Param: "1", Message: "count最小只能为1"},
+		{Field: "phone", Rule: "mobile", Message: "phone必须是手机号"},
+		{Field: "code", Rule: "order_code", Param: "8", Message: "code必须是8位订单号"},
+		{Field: "items[1].name", Rule: "required", Message: "name为必填字段"},
+	}
+	if len(fieldErrors) != len(want) {
+		t.Fatalf("unexpected errors %+v", fieldErrors)
+	}
+	for i, w := range want {
+		got := fieldErrors[i]
+		if got.Field != w.Field || got.Rule != w.Rule || got.Param != w.Param || got.Message != w.Message {
+			t.Errorf("error %d: got %+v, want %+v", i, got, w)
+		}
+	}
+
+	english := fieldErrors.Translate("en-US,en;q=0.9")
+	if english[0].Message != "user is a required field" || english[2].Message != "phone must be a mobile number" {
+		t.Errorf("unexpected english messages %q, %q", english[0].Message, english[2].Message)
+	}
+	if fieldErrors[0].Message != "user为必填字段" {
+		t.Error("Translate should not modify the original errors")
+	}
+
+	// 切片中的元素以下标作为前缀
+	err = validate(&[]validateItem{{Name: "a"}, {}})
+	if !errors.As(err, &fieldErrors) || len(fieldErrors) != 1 || fieldErrors[0].Field != "[1].name" {
+		t.Fatalf("unexpected slice errors %v", err)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	fieldErrors, ok := FieldErrors(&JSONFieldsError{Missing: []string{"user.name"}, Unknown: []string{"coupon"}}, "en")
+	if !ok || len(fieldErrors) != 2 {
+		t.Fatalf("unexpected field errors %+v", fieldErrors)
+	}
+	if fieldErrors[0].Message != "user.name is a required field" || fieldErrors[1].Message != "coupon is not allowed" {
+		t.Errorf("unexpected messages %q, %q", fieldErrors[0].Message, fieldErrors[1].Message)
+	}
+	if _, ok = FieldErrors(errors.New("unexpected EOF"), "zh"); ok {
+		t.Error("plain errors should not be converted")
+	}
+}
+
+// 只实现StructValidator的自定义验证器
+type plainValidator struct{}
+
+func (plainValidator) ValidateStruct(any) error { return nil }
+
+func (plainValidator) Engine() any { return nil }
+
+func TestPlainValidator(t *testing.T) {
+	defer func(v StructValidator) { Validator = v }(Validator)
+	Validator = plainValidator{}
+
+	fieldErrors, ok := FieldErrors(&JSONFieldsError{Missing: []string{"user"}}, "zh")
+	if !ok || len(fieldErrors) != 1 || fieldErrors[0].Message != "user为必填字段" {
+		t.Errorf("unexpected field errors %+v", fieldErrors)
+	}
+	if err := RegisterAlias("phone", "numeric,len=11", nil); err == nil {
+		t.Error("RegisterAlias should fail for validators without TranslatableValidator")
+	}
+}
@@ -1,6 +1,7 @@
 package binding
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"io"
@@ -18,8 +19,16 @@ func (x xmlBinding) Bind(request *http.Request, model any) error {
 	if request.Body == nil {
 		return errors.New("request Body is nil")
 	}
-	decoder := xml.NewDecoder(request.Body)
-	err := decoder.Decode(model)
+	return x.decode(request.Body, model)
+}
+
+// BindBody 从已读取的请求体中绑定
+func (x xmlBinding) BindBody(body []byte, model any) error {
+	return x.decode(bytes.NewReader(body), model)
+}
+
+func (x xmlBinding) decode(body io.Reader, model any) error {
+	err := xml.NewDecoder(body).Decode(model)
 	if err != nil && err != io.EOF {
 		return err
 	}
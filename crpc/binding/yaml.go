@@ -0,0 +1,36 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net/http"
+)
+
+type yamlBinding struct {
+}
+
+func (y yamlBinding) Name() string {
+	return "yaml"
+}
+
+// Bind 将YAML请求体反序列化到结构体中，字段名使用yaml标签
+func (y yamlBinding) Bind(request *http.Request, model any) error {
+	if request.Body == nil {
+		return errors.New("request Body is nil")
+	}
+	return y.decode(request.Body, model)
+}
+
+// BindBody 从已读取的请求体中绑定
+func (y yamlBinding) BindBody(body []byte, model any) error {
+	return y.decode(bytes.NewReader(body), model)
+}
+
+func (y yamlBinding) decode(body io.Reader, model any) error {
+	if err := yaml.NewDecoder(body).Decode(model); err != nil && err != io.EOF {
+		return err
+	}
+	return validate(model)
+}
@@ -0,0 +1,143 @@
+package crpc
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// 默认读取客户端IP的请求头，按顺序使用第一个能解析出IP的请求头
+var defaultRemoteIPHeaders = []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"}
+
+// SetTrustedProxies 设置可信代理列表，支持IP和CIDR，如"10.0.0.0/8"、"::1"；
+// 只有直接连接的对端在列表中时，ClientIP才会使用RemoteIPHeaders中的请求头，为空时不信任任何代理
+func (e *Engine) SetTrustedProxies(proxies []string) error {
+	cidrs := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return &net.ParseError{Type: "IP address", Text: proxy}
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			cidrs = append(cidrs, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return err
+		}
+		cidrs = append(cidrs, cidr)
+	}
+	e.trustedCIDRs = cidrs
+	return nil
+}
+
+// 判断ip是否为可信代理
+func (e *Engine) isTrustedProxy(ip net.IP) bool {
+	for _, cidr := range e.trustedCIDRs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP 获取客户端IP，直接连接的对端为可信代理时，从右向左取转发链中第一个不可信的地址，
+// 否则返回对端地址
+func (c *Context) ClientIP() string {
+	remoteIP := c.RemoteIP()
+	ip := net.ParseIP(remoteIP)
+	if ip == nil || c.engine == nil || !c.engine.isTrustedProxy(ip) {
+		return remoteIP
+	}
+	for _, header := range c.engine.RemoteIPHeaders {
+		if clientIP, ok := c.engine.forwardedClientIP(header, c.Request.Header.Values(header)); ok {
+			return clientIP
+		}
+	}
+	return remoteIP
+}
+
+// RemoteIP 获取直接连接的对端IP
+func (c *Context) RemoteIP() string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(c.Request.RemoteAddr))
+	if err != nil {
+		return strings.TrimSpace(c.Request.RemoteAddr)
+	}
+	return ip
+}
+
+// 从转发请求头中解析客户端IP，请求头不存在或含有无法解析的地址时返回false
+func (e *Engine) forwardedClientIP(header string, values []string) (string, bool) {
+	if len(values) == 0 {
+		return "", false
+	}
+	var chain []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if http.CanonicalHeaderKey(header) == "Forwarded" {
+				item = forwardedFor(item)
+			}
+			chain = append(chain, strings.TrimSpace(item))
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(chain[i])
+		if ip == nil {
+			return "", false
+		}
+		if i == 0 || !e.isTrustedProxy(ip) {
+			return ip.String(), true
+		}
+	}
+	return "", false
+}
+
+// 解析RFC 7239 Forwarded中一个代理节点的for参数，如for="[2001:db8::1]:4711"
+func forwardedFor(element string) string {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || !strings.EqualFold(key, "for") {
+			continue
+		}
+		value = strings.Trim(value, `"`)
+		if strings.HasPrefix(value, "[") {
+			if end := strings.Index(value, "]"); end > 0 {
+				return value[1:end]
+			}
+			return value
+		}
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			return host
+		}
+		return value
+	}
+	return ""
+}
+
+// 生成RFC 7239 Forwarded中的for参数，IPv6地址需要加方括号和引号
+func forwardedForValue(ip string) string {
+	if strings.Contains(ip, ":") {
+		return `for="[` + ip + `]"`
+	}
+	return "for=" + ip
+}
+
+// 网关转发前设置转发相关的请求头：X-Forwarded-For由httputil.ReverseProxy在Director之后追加对端地址，
+// 这里在已有的Forwarded后追加对端地址，并将X-Real-IP设为网关解析出的客户端IP
+func setForwardedHeaders(ctx *Context, request *http.Request) {
+	if forwarded := request.Header.Values("Forwarded"); len(forwarded) > 0 {
+		request.Header.Set("Forwarded", strings.Join(forwarded, ", ")+", "+forwardedForValue(ctx.RemoteIP()))
+	}
+	request.Header.Set("X-Real-IP", ctx.ClientIP())
+	request.Header.Set("X-Forwarded-Host", ctx.Request.Host)
+	if ctx.Request.TLS != nil {
+		request.Header.Set("X-Forwarded-Proto", "https")
+	} else {
+		request.Header.Set("X-Forwarded-Proto", "http")
+	}
+}
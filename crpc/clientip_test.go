@@ -0,0 +1,64 @@
+package crpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	engine := MakeEngine()
+	if err := engine.SetTrustedProxies([]string{"10.0.0.0/8", "::1"}); err != nil {
+		t.Fatal(err)
+	}
+	clientIP := func(remoteAddr string, header map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		return (&Context{engine: engine, Request: req}).ClientIP()
+	}
+	cases := []struct {
+		remoteAddr string
+		header     map[string]string
+		want       string
+	}{
+		// 对端不可信时忽略转发请求头
+		{"1.2.3.4:1000", map[string]string{"X-Forwarded-For": "9.9.9.9"}, "1.2.3.4"},
+		{"10.0.0.1:1000", nil, "10.0.0.1"},
+		{"10.0.0.1:1000", map[string]string{"X-Forwarded-For": "9.9.9.9, 5.6.7.8, 10.0.0.2"}, "5.6.7.8"},
+		{"10.0.0.1:1000", map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"10.0.0.1:1000", map[string]string{"X-Forwarded-For": "bad", "X-Real-IP": "5.6.7.8"}, "5.6.7.8"},
+		{"[::1]:1000", map[string]string{"Forwarded": `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`}, "2001:db8::1"},
+		{"[::1]:1000", map[string]string{"Forwarded": "for=unknown", "X-Forwarded-For": "5.6.7.8"}, "5.6.7.8"},
+	}
+	for _, c := range cases {
+		if got := clientIP(c.remoteAddr, c.header); got != c.want {
+			t.Errorf("ClientIP(%s, %v) = %s, want %s", c.remoteAddr, c.header, got, c.want)
+		}
+	}
+
+	if err := engine.SetTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("expected error for invalid proxy")
+	}
+}
+
+func TestSetForwardedHeaders(t *testing.T) {
+	engine := MakeEngine()
+	if err := engine.SetTrustedProxies([]string{"10.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	in := httptest.NewRequest(http.MethodGet, "http://gateway.example.com/order/find", nil)
+	in.RemoteAddr = "10.0.0.1:1000"
+	in.Header.Set("Forwarded", "for=5.6.7.8")
+	out := in.Clone(in.Context())
+	setForwardedHeaders(&Context{engine: engine, Request: in}, out)
+	if got := out.Header.Get("Forwarded"); got != "for=5.6.7.8, for=10.0.0.1" {
+		t.Fatalf("unexpected Forwarded %q", got)
+	}
+	if out.Header.Get("X-Real-IP") != "5.6.7.8" || out.Header.Get("X-Forwarded-Host") != "gateway.example.com" ||
+		out.Header.Get("X-Forwarded-Proto") != "http" {
+		t.Fatalf("unexpected header %v", out.Header)
+	}
+}
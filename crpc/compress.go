@@ -0,0 +1,311 @@
+package crpc
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressConfig 响应压缩配置
+type CompressConfig struct {
+	Level                int      // 压缩级别，默认为gzip.DefaultCompression
+	MinLength            int      // 响应体小于该长度时不压缩，默认1024
+	ExcludedContentTypes []string // 不压缩的内容类型前缀，默认为图片、音视频、字体和压缩包等本身已压缩的类型
+}
+
+const defaultCompressMinLength = 1024
+
+var defaultExcludedContentTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+	"application/x-xz", "application/x-7z-compressed", "application/x-rar-compressed", "application/zstd",
+}
+
+// Compress 响应压缩中间件，根据Accept-Encoding选择gzip或deflate；
+// 响应体小于MinLength、内容类型已压缩或已设置Content-Encoding时原样返回，
+// HEAD请求、Range请求和协议升级请求不压缩，调用Flush时会先刷新压缩数据，可用于流式响应
+func Compress(conf CompressConfig) MiddleWareFunc {
+	if conf.Level == 0 {
+		conf.Level = gzip.DefaultCompression
+	}
+	if conf.Level < gzip.HuffmanOnly || conf.Level > gzip.BestCompression {
+		panic("crpc: invalid compress level " + strconv.Itoa(conf.Level))
+	}
+	if conf.MinLength <= 0 {
+		conf.MinLength = defaultCompressMinLength
+	}
+	if conf.ExcludedContentTypes == nil {
+		conf.ExcludedContentTypes = defaultExcludedContentTypes
+	}
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, conf.Level)
+			return w
+		}},
+		"deflate": {New: func() any {
+			w, _ := flate.NewWriter(io.Discard, conf.Level)
+			return w
+		}},
+	}
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			addVary(ctx.Writer.Header(), "Accept-Encoding")
+			request := ctx.Request
+			encoding := negotiateEncoding(request.Header.Get("Accept-Encoding"))
+			if encoding == "" || request.Method == http.MethodHead ||
+				request.Header.Get("Range") != "" || request.Header.Get("Upgrade") != "" {
+				next(ctx)
+				return
+			}
+			writer := &compressWriter{
+				ResponseWriter: ctx.Writer,
+				conf:           &conf,
+				encoding:       encoding,
+				pool:           pools[encoding],
+				status:         http.StatusOK,
+			}
+			panicked := true
+			defer func(original ResponseWriter) {
+				if panicked {
+					writer.discard()
+				} else {
+					writer.close()
+				}
+				ctx.Writer = original
+			}(ctx.Writer)
+			ctx.Writer = writer
+			next(ctx)
+			panicked = false
+		}
+	}
+}
+
+// 根据Accept-Encoding选择压缩算法，q值相同时优先gzip，都不接受时返回空字符串
+func negotiateEncoding(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(params[2:], 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+	quality := func(name string) float64 {
+		if q, ok := qualities[name]; ok {
+			return q
+		}
+		return qualities["*"]
+	}
+	gzipQ, deflateQ := quality("gzip"), quality("deflate")
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return "gzip"
+	case deflateQ > 0:
+		return "deflate"
+	}
+	return ""
+}
+
+// 添加Vary响应头，已存在时不重复添加
+func addVary(header http.Header, value string) {
+	for _, vary := range header.Values("Vary") {
+		for _, v := range strings.Split(vary, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), value) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", value)
+}
+
+// 压缩器，gzip.Writer和flate.Writer都实现了该接口
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// 压缩中间件使用的ResponseWriter，在确定是否压缩之前缓存响应头和不超过MinLength的响应体
+type compressWriter struct {
+	ResponseWriter
+	conf        *CompressConfig
+	encoding    string
+	pool        *sync.Pool
+	status      int
+	wroteHeader bool
+	decided     bool
+	compressor  compressor
+	buf         []byte
+	size        int
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader || w.decided {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	w.wroteHeader = true
+	if !w.decided {
+		_ = w.decide(true)
+	}
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	w.wroteHeader = true
+	w.size += len(data)
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.conf.MinLength {
+			return len(data), nil
+		}
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.compressor != nil {
+		return w.compressor.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush 先写出已缓存和已压缩的数据，再刷新底层连接
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		_ = w.decide(true)
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+func (w *compressWriter) Status() int {
+	if w.decided {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+// Size 处理函数写入的未压缩的响应体大小
+func (w *compressWriter) Size() int {
+	return w.size
+}
+
+func (w *compressWriter) Written() bool {
+	return w.wroteHeader || w.ResponseWriter.Written()
+}
+
+// 确定是否压缩并写出响应头和缓存的数据；
+// streaming为true时响应体长度未知，只要内容类型可以压缩就压缩
+func (w *compressWriter) decide(streaming bool) error {
+	w.decided = true
+	header := w.ResponseWriter.Header()
+	if header.Get("Content-Type") == "" && len(w.buf) > 0 {
+		// 压缩后net/http无法再根据响应体推断内容类型
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	if w.shouldCompress(header, streaming) {
+		header.Del("Content-Length")
+		header.Del("Accept-Ranges")
+		header.Set("Content-Encoding", w.encoding)
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			// 压缩后与原始内容不再逐字节相同，强ETag改为弱ETag
+			header.Set("ETag", "W/"+etag)
+		}
+		w.compressor = w.pool.Get().(compressor)
+		w.compressor.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) shouldCompress(header http.Header, streaming bool) bool {
+	if w.status < http.StatusOK || w.status == http.StatusNoContent ||
+		w.status == http.StatusNotModified || w.status == http.StatusPartialContent {
+		return false
+	}
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	if length := header.Get("Content-Length"); length != "" {
+		n, err := strconv.Atoi(length)
+		if err != nil || n < w.conf.MinLength {
+			return false
+		}
+	} else if !streaming && len(w.buf) < w.conf.MinLength {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, excluded := range w.conf.ExcludedContentTypes {
+		if strings.HasPrefix(mediaType, strings.ToLower(excluded)) {
+			return false
+		}
+	}
+	return true
+}
+
+// 处理函数panic时丢弃缓存的数据，压缩器不写出结尾直接归还，避免输出被截断的压缩流；
+// 尚未写出响应头时外层的Recovery仍可以正常返回错误响应
+func (w *compressWriter) discard() {
+	w.buf = nil
+	if w.compressor != nil {
+		w.compressor.Reset(io.Discard)
+		w.pool.Put(w.compressor)
+		w.compressor = nil
+	}
+}
+
+// 处理函数返回后写出剩余数据并归还压缩器
+func (w *compressWriter) close() {
+	if !w.decided {
+		if !w.wroteHeader {
+			return
+		}
+		_ = w.decide(false)
+	}
+	if w.compressor != nil {
+		_ = w.compressor.Close()
+		w.pool.Put(w.compressor)
+		w.compressor = nil
+	}
+}
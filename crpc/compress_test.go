@@ -0,0 +1,188 @@
+package crpc
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"github/CeerDecy/RpcFrameWork/crpc/crpcLogger"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                           "",
+		"gzip, deflate, br":          "gzip",
+		"deflate":                    "deflate",
+		"gzip;q=0.5, deflate;q=0.8":  "deflate",
+		"gzip;q=0, deflate;q=0":      "",
+		"*":                          "gzip",
+		"*;q=0.1, gzip;q=0":          "deflate",
+		"identity":                   "",
+		"GZIP;q=1.0, deflate;q=bad":  "gzip",
+		"br;q=1.0, *;q=0, deflate=1": "",
+	}
+	for header, want := range cases {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("crpc ", 500)
+	engine := MakeEngine()
+	engine.UseMiddleWare(Compress(CompressConfig{}))
+	group := engine.CreateGroup("")
+	group.Get("/large", func(ctx *Context) {
+		ctx.String(http.StatusOK, body)
+	})
+	group.Get("/small", func(ctx *Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	group.Get("/png", func(ctx *Context) {
+		ctx.Writer.Header().Set("Content-Type", "image/png")
+		_, _ = ctx.Writer.Write([]byte(body))
+	})
+	group.Get("/stream", func(ctx *Context) {
+		ctx.Writer.Header().Set("Content-Type", "text/event-stream")
+		_, _ = ctx.Writer.Write([]byte("data: 1\n\n"))
+		ctx.Writer.Flush()
+		_, _ = ctx.Writer.Write([]byte("data: 2\n\n"))
+	})
+	request := func(path, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		writer := httptest.NewRecorder()
+		engine.ServeHTTP(writer, req)
+		return writer
+	}
+
+	w := request("/large", "gzip, deflate")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != body {
+		t.Fatalf("unexpected gzip body length %d", len(data))
+	}
+
+	w = request("/large", "deflate")
+	if w.Header().Get("Content-Encoding") != "deflate" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	if data, _ := io.ReadAll(flate.NewReader(w.Body)); string(data) != body {
+		t.Fatalf("unexpected deflate body length %d", len(data))
+	}
+
+	for path, encoding := range map[string]string{"/large": "", "/small": "gzip", "/png": "gzip"} {
+		w = request(path, encoding)
+		if w.Header().Get("Content-Encoding") != "" || w.Code != http.StatusOK {
+			t.Fatalf("%s should not be compressed: %d %v", path, w.Code, w.Header())
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("%s missing Vary", path)
+		}
+	}
+
+	w = request("/stream", "gzip")
+	if w.Header().Get("Content-Encoding") != "gzip" || !w.Flushed {
+		t.Fatalf("stream should be compressed and flushed: %v", w.Header())
+	}
+	reader, err = gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != "data: 1\n\ndata: 2\n\n" {
+		t.Fatalf("unexpected stream body %q", data)
+	}
+}
+
+func TestCompressFile(t *testing.T) {
+	content := strings.Repeat("0123456789", 300)
+	filename := filepath.Join(t.TempDir(), "data.txt")
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine := MakeEngine()
+	engine.UseMiddleWare(Compress(CompressConfig{}))
+	engine.CreateGroup("").Get("/file", func(ctx *Context) {
+		ctx.File(filename)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Content-Length") != "" ||
+		w.Header().Get("Accept-Ranges") != "" {
+		t.Fatalf("unexpected header %v", w.Header())
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != content {
+		t.Fatalf("unexpected body length %d", len(data))
+	}
+
+	// Range请求不压缩，返回原始内容的对应片段
+	req = httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Range", "bytes=10-19")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusPartialContent || w.Header().Get("Content-Encoding") != "" || w.Body.String() != "0123456789" {
+		t.Fatalf("unexpected range response %d %v %q", w.Code, w.Header(), w.Body.String())
+	}
+}
+
+func TestCompressPanic(t *testing.T) {
+	engine := MakeEngine()
+	engine.Logger = crpcLogger.TextLogger()
+	engine.Logger.Writers = nil
+	engine.Logger.AddWriter(io.Discard, -1)
+	engine.UseMiddleWare(Recovery, Compress(CompressConfig{}))
+	group := engine.CreateGroup("")
+	group.Get("/buffered", func(ctx *Context) {
+		_, _ = ctx.Writer.Write([]byte("partial"))
+		panic(errors.New("boom"))
+	})
+	group.Get("/streaming", func(ctx *Context) {
+		_, _ = ctx.Writer.Write([]byte(strings.Repeat("crpc ", 500)))
+		panic(errors.New("boom"))
+	})
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		writer := httptest.NewRecorder()
+		engine.ServeHTTP(writer, req)
+		return writer
+	}
+
+	// 缓存中的数据被丢弃，由Recovery返回未压缩的错误响应
+	writer := request("/buffered")
+	if writer.Code != http.StatusInternalServerError || writer.Header().Get("Content-Encoding") != "" ||
+		strings.Contains(writer.Body.String(), "partial") {
+		t.Errorf("unexpected response %d %v %q", writer.Code, writer.Header(), writer.Body.String())
+	}
+	// 已经开始压缩时不写出gzip结尾，客户端能发现响应不完整
+	writer = request("/streaming")
+	reader, err := gzip.NewReader(writer.Body)
+	if err == nil {
+		_, err = io.ReadAll(reader)
+	}
+	if err == nil {
+		t.Error("truncated compressed response should not be a complete gzip stream")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/BurntSushi/toml"
 	"github/CeerDecy/RpcFrameWork/crpc/crpcLogger"
 	"os"
+	"strings"
 )
 
 var Conf = &CRConfig{
@@ -12,19 +13,41 @@ var Conf = &CRConfig{
 }
 
 func init() {
-	loadToml()
+	// 注册-conf参数供main中的flag.Parse识别，这里不调用flag.Parse，
+	// 否则其他包（如testing）在init之后注册的参数会被当作未定义的参数
+	configFile := flag.String("conf", "conf/app.toml", "app config file")
+	loadToml(confArg(os.Args[1:], *configFile))
 }
 
-func loadToml() {
-	configFile := flag.String("conf", "conf/app.toml", "app config file")
-	flag.Parse()
-	if _, err := os.Stat(*configFile); err != nil {
-		Conf.logger.Debug("config", "conf/app.toml file not load,because not exist")
+// 从命令行参数中查找-conf的值，支持-conf path、-conf=path以及--conf的形式，没有时返回def
+func confArg(args []string, def string) string {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		if name == "conf" && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(name, "conf=") {
+			return name[len("conf="):]
+		}
+	}
+	return def
+}
+
+func loadToml(configFile string) {
+	if _, err := os.Stat(configFile); err != nil {
+		Conf.logger.Debug("config", configFile+" file not load,because not exist")
 		return
 	}
-	_, err := toml.DecodeFile(*configFile, Conf)
+	_, err := toml.DecodeFile(configFile, Conf)
 	if err != nil {
-		Conf.logger.Error("config", "conf/app.toml decode fail check format")
+		Conf.logger.Error("config", configFile+" decode fail check format")
 		panic(err)
 	}
 }
@@ -0,0 +1,21 @@
+package config
+
+import "testing"
+
+func TestConfArg(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-conf", "a.toml"}, "a.toml"},
+		{[]string{"-v", "--conf=b.toml"}, "b.toml"},
+		{[]string{"-test.v", "-test.run", "TestConfArg"}, "conf/app.toml"},
+		{[]string{"--", "-conf", "c.toml"}, "conf/app.toml"},
+		{[]string{"-conf"}, "conf/app.toml"},
+	}
+	for _, test := range tests {
+		if got := confArg(test.args, "conf/app.toml"); got != test.want {
+			t.Errorf("confArg(%q) = %q, want %q", test.args, got, test.want)
+		}
+	}
+}
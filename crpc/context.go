@@ -1,6 +1,7 @@
 package crpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github/CeerDecy/RpcFrameWork/crpc/binding"
@@ -16,29 +17,122 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 )
 
 const defaultMaxMemory = 32 << 20 // 32 MB
 
 type Context struct {
-	Writer                http.ResponseWriter
+	Writer                ResponseWriter
 	Request               *http.Request
 	engine                *Engine
 	queryCache            url.Values
 	formCache             url.Values
 	disallowUnknownFields bool // 是否需要开启Json属性不存在校验
 	isValidate            bool // 是否开启结构体校验
-	code                  int
 	Logger                *crpcLogger.Logger
 	Keys                  map[string]any
 	mu                    sync.RWMutex
 	sameSite              http.SameSite
+	params                Params // 路由匹配到的路径参数
+	writermem             responseWriter
+	aborted               bool       // 是否已终止处理链
+	next                  HandleFunc // 当前中间件之后的处理链，供Next调用
+	nextRan               []bool     // 正在执行的各层中间件是否已经执行过next
+	timedOut              bool       // 是否已被Timeout中间件判定为超时
+	fullPath              string     // 匹配到的路由路径
+}
+
+// 重置上下文，每次从Pool中取出时调用，避免上一个请求的数据泄漏到当前请求
+func (c *Context) reset(writer http.ResponseWriter, request *http.Request) {
+	c.writermem.reset(writer)
+	c.Writer = &c.writermem
+	c.Request = request
+	c.Logger = c.engine.Logger
+	c.queryCache = nil
+	c.formCache = nil
+	c.disallowUnknownFields = false
+	c.isValidate = false
+	c.Keys = nil
+	c.sameSite = http.SameSiteDefaultMode
+	c.params = c.params[:0]
+	c.aborted = false
+	c.next = nil
+	c.nextRan = c.nextRan[:0]
+	c.timedOut = false
+	c.fullPath = ""
+}
+
+// Copy 返回当前上下文的只读副本，可以在处理函数返回后交给协程（如pool.Pool.Submit）使用；
+// 副本中的Keys和路径参数为拷贝，不随请求取消，写响应会返回ErrContextCopied
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		engine:                c.engine,
+		queryCache:            c.queryCache,
+		formCache:             c.formCache,
+		disallowUnknownFields: c.disallowUnknownFields,
+		isValidate:            c.isValidate,
+		Logger:                c.Logger,
+		sameSite:              c.sameSite,
+		params:                make(Params, len(c.params)),
+		aborted:               c.aborted,
+		fullPath:              c.fullPath,
+	}
+	copy(cp.params, c.params)
+	if c.Request != nil {
+		cp.Request = c.Request.WithContext(detachedContext{parent: c.Request.Context()})
+	}
+	header := http.Header{}
+	if c.Writer != nil {
+		header = c.Writer.Header().Clone()
+		cp.writermem.status = c.Writer.Status()
+		cp.writermem.size = c.Writer.Size()
+	}
+	cp.writermem.ResponseWriter = &copiedResponseWriter{header: header}
+	cp.writermem.written = true
+	cp.Writer = &cp.writermem
+	c.mu.RLock()
+	if c.Keys != nil {
+		cp.Keys = make(map[string]any, len(c.Keys))
+		for k, v := range c.Keys {
+			cp.Keys[k] = v
+		}
+	}
+	c.mu.RUnlock()
+	return cp
+}
+
+// 只继承父上下文中的值，不随父上下文取消
+type detachedContext struct {
+	parent context.Context
+}
+
+func (d detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (d detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key any) any {
+	return d.parent.Value(key)
 }
 
 func (c *Context) SetSameSite(site http.SameSite) {
 	c.sameSite = site
 }
 
+// ContentType 获取请求的Content-Type，不包含charset等参数
+func (c *Context) ContentType() string {
+	contentType, _, _ := strings.Cut(c.Request.Header.Get("Content-Type"), ";")
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
 func (c *Context) Set(key string, value any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -55,15 +149,140 @@ func (c *Context) Get(key string) (value any, ok bool) {
 	return
 }
 
-// DisallowUnknownFields 是否解析未知字段
+// Deadline 返回请求上下文的截止时间，实现context.Context
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Request == nil {
+		return
+	}
+	return c.Request.Context().Deadline()
+}
+
+// Done 返回请求上下文的Done通道，客户端断开连接或服务退出时关闭，实现context.Context
+func (c *Context) Done() <-chan struct{} {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Done()
+}
+
+// Err 返回请求上下文结束的原因，实现context.Context
+func (c *Context) Err() error {
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Err()
+}
+
+// Value key为string时先从Keys中查找，找不到再从请求上下文中查找，实现context.Context
+func (c *Context) Value(key any) any {
+	if k, ok := key.(string); ok {
+		if value, exists := c.Get(k); exists {
+			return value
+		}
+	}
+	if c.Request == nil {
+		return nil
+	}
+	return c.Request.Context().Value(key)
+}
+
+// WithTimeout 基于当前请求创建一个带超时的上下文，用于控制下游调用的耗时；
+// Context会被复用，不要在处理函数返回后继续使用返回的上下文
+func (c *Context) WithTimeout(timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c, timeout)
+}
+
+// WithDeadline 基于当前请求创建一个带截止时间的上下文
+func (c *Context) WithDeadline(deadline time.Time) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(c, deadline)
+}
+
+// Next 执行后续的处理链，只在中间件中使用，同一个中间件中多次调用只会执行一次
+func (c *Context) Next() {
+	if next := c.next; next != nil {
+		c.next = nil
+		next(c)
+	}
+}
+
+// Abort 终止处理链，尚未执行的中间件和处理函数不再执行，后置中间件仍会执行
+func (c *Context) Abort() {
+	c.aborted = true
+}
+
+// IsTimeout 请求是否已被Timeout中间件判定为超时
+func (c *Context) IsTimeout() bool {
+	return c.timedOut
+}
+
+// IsAborted 处理链是否已被终止
+func (c *Context) IsAborted() bool {
+	return c.aborted
+}
+
+// AbortWithStatus 终止处理链并写入状态码
+func (c *Context) AbortWithStatus(code int) {
+	c.Abort()
+	c.Writer.WriteHeader(code)
+}
+
+// AbortWithStatusJSON 终止处理链并返回JSON数据
+func (c *Context) AbortWithStatusJSON(code int, data any) {
+	c.Abort()
+	c.JSON(code, data)
+}
+
+// Status 获取响应的状态码，尚未写出时为200
+func (c *Context) Status() int {
+	return c.Writer.Status()
+}
+
+// Size 获取已写入的响应体大小
+func (c *Context) Size() int {
+	return c.Writer.Size()
+}
+
+// Written 响应头是否已经写出
+func (c *Context) Written() bool {
+	return c.Writer.Written()
+}
+
+// Param 获取路径参数，如路由 /user/:id 中的id
+func (c *Context) Param(name string) string {
+	return c.params.ByName(name)
+}
+
+// FullPath 获取匹配到的路由路径，如/user/:id，未匹配到路由时为空
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
+// Params 获取所有路径参数
+func (c *Context) Params() Params {
+	return c.params
+}
+
+// DisallowUnknownFields 之后绑定JSON时，请求中存在结构体没有的字段则返回错误
 func (c *Context) DisallowUnknownFields() {
 	c.disallowUnknownFields = true
 }
 
+// IsValidate 之后绑定JSON时检查带有crpc:"require"标签的字段是否存在
 func (c *Context) IsValidate() {
 	c.isValidate = true
 }
 
+// 根据当前请求的设置选择JSON绑定器
+func (c *Context) jsonBinding() binding.BindingBody {
+	if !c.disallowUnknownFields && !c.isValidate {
+		return binding.JSON
+	}
+	return binding.NewJSON(binding.JSONOptions{
+		DisallowUnknownFields: c.disallowUnknownFields,
+		CheckRequired:         c.isValidate,
+	})
+}
+
 // BindJson 以绑定器的形式将Json参数反序列化
 func (c *Context) BindJson(model any) error {
 	return c.MustBindWith(model, binding.JSON)
@@ -74,14 +293,100 @@ func (c *Context) BindXML(model any) error {
 	return c.MustBindWith(model, binding.XML)
 }
 
+// BindURI 根据uri标签将路径参数绑定到结构体中
+func (c *Context) BindURI(model any) error {
+	m := make(map[string][]string, len(c.params))
+	for _, p := range c.params {
+		m[p.Key] = []string{p.Value}
+	}
+	return binding.URI.BindUri(m, model)
+}
+
+// BindQuery 根据form标签将查询参数绑定到结构体中
+func (c *Context) BindQuery(model any) error {
+	return c.MustBindWith(model, binding.Query)
+}
+
+// BindForm 根据form标签将查询参数和表单绑定到结构体中，multipart表单中的文件使用binding.FormMultipart绑定
+func (c *Context) BindForm(model any) error {
+	return c.MustBindWith(model, binding.Form)
+}
+
+// BindHeader 根据header标签将请求头绑定到结构体中
+func (c *Context) BindHeader(model any) error {
+	return c.MustBindWith(model, binding.Header)
+}
+
+// BindCookie 根据cookie标签将Cookie绑定到结构体中
+func (c *Context) BindCookie(model any) error {
+	return c.MustBindWith(model, binding.Cookie)
+}
+
+// ShouldBind 根据请求方法和Content-Type自动选择绑定器，见binding.Default
+func (c *Context) ShouldBind(model any) error {
+	return c.ShouldBindWith(model, binding.Default(c.Request.Method, c.ContentType()))
+}
+
+// Bind 同ShouldBind，绑定失败时终止处理链并返回400及错误信息
+func (c *Context) Bind(model any) error {
+	err := c.ShouldBind(model)
+	if err != nil {
+		c.AbortWithBindError(err)
+	}
+	return err
+}
+
+// BodyBytesKey ShouldBindBodyWith缓存请求体时在Context.Keys中使用的键
+const BodyBytesKey = "_crpc/body_bytes"
+
+// ShouldBindBodyWith 读取并缓存请求体后绑定，同一个请求可以多次调用，如先尝试JSON再尝试XML
+func (c *Context) ShouldBindBodyWith(model any, bind binding.BindingBody) error {
+	var body []byte
+	if cached, ok := c.Get(BodyBytesKey); ok {
+		body, _ = cached.([]byte)
+	}
+	if body == nil {
+		if c.Request.Body == nil {
+			return errors.New("request Body is nil")
+		}
+		data, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		body = data
+		c.Set(BodyBytesKey, body)
+	}
+	if bind == binding.JSON {
+		bind = c.jsonBinding()
+	}
+	return bind.BindBody(body, model)
+}
+
+// AbortWithBindError 终止处理链并返回400，参数校验错误按Accept-Language翻译（支持zh、en），
+// 并在errors中列出每个字段的错误：{"code":400,"msg":"...","errors":[{"field":"name","rule":"required","message":"..."}]}
+func (c *Context) AbortWithBindError(err error) {
+	response := map[string]any{
+		"code": http.StatusBadRequest,
+		"msg":  err.Error(),
+	}
+	if fieldErrors, ok := binding.FieldErrors(err, c.Request.Header.Get("Accept-Language")); ok {
+		response["msg"] = fieldErrors.Error()
+		response["errors"] = fieldErrors
+	}
+	c.AbortWithStatusJSON(http.StatusBadRequest, response)
+}
+
 // MustBindWith 必须绑定
 func (c *Context) MustBindWith(model any, bind binding.Binding) error {
 	err := c.ShouldBindWith(model, bind)
 	return err
 }
 
-// ShouldBindWith 尝试绑定
+// ShouldBindWith 尝试绑定，使用binding.JSON时应用DisallowUnknownFields和IsValidate的设置
 func (c *Context) ShouldBindWith(model any, bind binding.Binding) error {
+	if bind == binding.JSON {
+		bind = c.jsonBinding()
+	}
 	return bind.Bind(c.Request, model)
 }
 
@@ -123,8 +428,11 @@ func (c *Context) MultipartForm() (*multipart.Form, error) {
 	return c.Request.MultipartForm, err
 }
 
-// 初始化Post表单参数
+// 初始化Post表单参数，同一个请求只解析一次
 func (c *Context) initPostFormCache() {
+	if c.formCache != nil {
+		return
+	}
 	if c.Request != nil {
 		if err := c.Request.ParseMultipartForm(defaultMaxMemory); err != nil {
 			if !errors.Is(err, http.ErrNotMultipart) {
@@ -165,8 +473,11 @@ func (c *Context) GetPostForm(key string) (string, bool) {
 	return "", false
 }
 
-// 初始化参数缓存
+// 初始化参数缓存，同一个请求只解析一次
 func (c *Context) initQueryCache() {
+	if c.queryCache != nil {
+		return
+	}
 	if c.Request != nil {
 		c.queryCache = c.Request.URL.Query()
 	} else {
@@ -311,6 +622,21 @@ func (c *Context) Redirect(status int, url string) {
 	c.Render(status, &render.Redirect{Code: status, Req: c.Request, Location: url})
 }
 
+// URLFor 根据路由名称生成URL，参数规则同 Engine.URLFor
+func (c *Context) URLFor(name string, params ...any) (string, error) {
+	return c.engine.URLFor(name, params...)
+}
+
+// RedirectToRoute 重定向到指定名称的路由
+func (c *Context) RedirectToRoute(status int, name string, params ...any) error {
+	location, err := c.engine.URLFor(name, params...)
+	if err != nil {
+		return err
+	}
+	c.Redirect(status, location)
+	return nil
+}
+
 // String 字符串格式化
 func (c *Context) String(status int, format string, values ...any) {
 	c.Render(status, &render.String{Format: format, Data: values})
@@ -318,7 +644,6 @@ func (c *Context) String(status int, format string, values ...any) {
 
 func (c *Context) Render(status int, render render.Render) {
 	err := render.Render(c.Writer, status)
-	c.code = status
 	if err != nil {
 		c.Logger.Error("Render", err.Error())
 		return
@@ -0,0 +1,205 @@
+package crpc
+
+import (
+	"context"
+	"github/CeerDecy/RpcFrameWork/crpc/binding"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBindURI(t *testing.T) {
+	ctx := &Context{params: Params{{Key: "id", Value: "1001"}, {Key: "name", Value: "ceer"}}}
+	var user struct {
+		Id   int64  `uri:"id"`
+		Name string `uri:"name"`
+	}
+	if err := ctx.BindURI(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != 1001 || user.Name != "ceer" {
+		t.Errorf("unexpected result %+v", user)
+	}
+	if ctx.Param("id") != "1001" || ctx.Param("age") != "" {
+		t.Errorf("unexpected param %q", ctx.Param("id"))
+	}
+}
+
+type ctxKey struct{}
+
+func TestContextAsContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	ctx := &Context{Request: httptest.NewRequest("GET", "/", nil).WithContext(parent)}
+	ctx.Set("user", "ceer")
+	var c context.Context = ctx
+	if c.Value("user") != "ceer" || c.Value(ctxKey{}) != "request" || c.Value("none") != nil {
+		t.Errorf("unexpected values %v %v", c.Value("user"), c.Value(ctxKey{}))
+	}
+	timeoutCtx, stop := ctx.WithTimeout(time.Minute)
+	defer stop()
+	if _, ok := timeoutCtx.Deadline(); !ok {
+		t.Error("WithTimeout should set a deadline")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("context should not be done yet")
+	}
+	cancel()
+	select {
+	case <-timeoutCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("cancelling the request should cancel derived contexts")
+	}
+	if ctx.Err() != context.Canceled || timeoutCtx.Err() != context.Canceled {
+		t.Errorf("unexpected errors %v %v", ctx.Err(), timeoutCtx.Err())
+	}
+}
+
+func TestContextReset(t *testing.T) {
+	engine := MakeEngine()
+	ctx := engine.allocateContext().(*Context)
+	ctx.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/user?id=1", nil))
+	ctx.Set("jwt_claims", "ceer")
+	ctx.SetSameSite(http.SameSiteStrictMode)
+	ctx.DisallowUnknownFields()
+	ctx.IsValidate()
+	ctx.Abort()
+	ctx.params = append(ctx.params, Param{Key: "id", Value: "1"})
+	ctx.Writer.WriteHeader(http.StatusNotFound)
+	if ctx.GetQuery("id") != "1" {
+		t.Fatal("unexpected query")
+	}
+
+	ctx.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/user?id=2", nil))
+	if _, ok := ctx.Get("jwt_claims"); ok || ctx.Keys != nil {
+		t.Error("Keys should be cleared")
+	}
+	if ctx.GetQuery("id") != "2" {
+		t.Errorf("query cache should be cleared, got %q", ctx.GetQuery("id"))
+	}
+	if ctx.sameSite != http.SameSiteDefaultMode || ctx.disallowUnknownFields || ctx.isValidate ||
+		ctx.IsAborted() || len(ctx.Params()) != 0 || ctx.Status() != http.StatusOK || ctx.Written() {
+		t.Errorf("context was not reset: %+v", ctx)
+	}
+}
+
+func TestContextCopy(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	engine := MakeEngine()
+	ctx := engine.allocateContext().(*Context)
+	ctx.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/user/1?name=ceer", nil).WithContext(parent))
+	ctx.Set("user", "ceer")
+	ctx.params = append(ctx.params, Param{Key: "id", Value: "1"})
+	ctx.String(http.StatusCreated, "ok")
+
+	cp := ctx.Copy()
+	cancel()
+	ctx.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/other", nil))
+
+	if value, _ := cp.Get("user"); value != "ceer" || cp.Param("id") != "1" || cp.GetQuery("name") != "ceer" {
+		t.Errorf("copy should keep a snapshot of the request data")
+	}
+	if cp.Status() != http.StatusCreated || cp.Size() != 2 {
+		t.Errorf("unexpected status %d size %d", cp.Status(), cp.Size())
+	}
+	if cp.Err() != nil {
+		t.Error("copy should not be cancelled with the request")
+	}
+	if _, err := cp.Writer.Write([]byte("late")); err != ErrContextCopied {
+		t.Errorf("expected ErrContextCopied, got %v", err)
+	}
+}
+
+func TestShouldBind(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" form:"name" yaml:"name" validate:"required"`
+		Age  int    `json:"age" xml:"age" form:"age" yaml:"age"`
+	}
+	engine := MakeEngine()
+	bind := func(method, target, contentType, body string) (*Context, user, error) {
+		ctx := engine.allocateContext().(*Context)
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		ctx.reset(httptest.NewRecorder(), req)
+		var u user
+		err := ctx.ShouldBind(&u)
+		return ctx, u, err
+	}
+	cases := []struct {
+		method, target, contentType, body string
+	}{
+		{"POST", "/", "application/json; charset=utf-8", `{"name":"ceer","age":18}`},
+		{"POST", "/", "text/xml", `<user><name>ceer</name><age>18</age></user>`},
+		{"POST", "/", "application/x-www-form-urlencoded", "name=ceer&age=18"},
+		{"PUT", "/", "application/yaml", "name: ceer\nage: 18\n"},
+		{"GET", "/?name=ceer&age=18", "", ""},
+	}
+	for _, c := range cases {
+		_, u, err := bind(c.method, c.target, c.contentType, c.body)
+		if err != nil || u.Name != "ceer" || u.Age != 18 {
+			t.Errorf("%s %s: got %+v, %v", c.method, c.contentType, u, err)
+		}
+	}
+
+	ctx, _, _ := bind("POST", "/", "application/json", `{"age":18}`)
+	var u user
+	if err := ctx.Bind(&u); err == nil || !ctx.IsAborted() {
+		t.Fatal("Bind should abort on validation error")
+	}
+	recorder := ctx.Writer.(*responseWriter).ResponseWriter.(*httptest.ResponseRecorder)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), `"field":"name","rule":"required","message":"name为必填字段"`) {
+		t.Fatalf("unexpected response %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	// 错误信息按Accept-Language翻译
+	ctx, _, _ = bind("POST", "/", "application/json", `{"age":18}`)
+	ctx.Request.Header.Set("Accept-Language", "en-US,en;q=0.9")
+	_ = ctx.Bind(&u)
+	recorder = ctx.Writer.(*responseWriter).ResponseWriter.(*httptest.ResponseRecorder)
+	if !strings.Contains(recorder.Body.String(), `"msg":"name is a required field"`) {
+		t.Fatalf("unexpected response %s", recorder.Body.String())
+	}
+}
+
+func TestShouldBindBodyWith(t *testing.T) {
+	type a struct {
+		Name string `json:"name" validate:"required"`
+	}
+	type b struct {
+		Title string `json:"title" validate:"required"`
+	}
+	ctx := MakeEngine().allocateContext().(*Context)
+	ctx.reset(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(`{"title":"crpc"}`)))
+	if err := ctx.ShouldBindBodyWith(&a{}, binding.JSON); err == nil {
+		t.Fatal("expected validation error for a")
+	}
+	var target b
+	if err := ctx.ShouldBindBodyWith(&target, binding.JSON); err != nil || target.Title != "crpc" {
+		t.Fatalf("second bind failed: %+v %v", target, err)
+	}
+}
+
+func TestBindJsonOptions(t *testing.T) {
+	type user struct {
+		Name string `json:"name" crpc:"require"`
+	}
+	engine := MakeEngine()
+	bind := func(body string, setup func(ctx *Context)) error {
+		ctx := engine.allocateContext().(*Context)
+		ctx.reset(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		setup(ctx)
+		return ctx.BindJson(&user{})
+	}
+	if err := bind(`{"age":1}`, func(*Context) {}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := bind(`{"name":"ceer","age":1}`, (*Context).DisallowUnknownFields); err == nil || !strings.Contains(err.Error(), "unknown field age") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+	if err := bind(`{"age":1}`, (*Context).IsValidate); err == nil || !strings.Contains(err.Error(), "miss field name") {
+		t.Fatalf("expected missing field error, got %v", err)
+	}
+}
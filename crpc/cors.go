@@ -0,0 +1,137 @@
+package crpc
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowOrigins     []string                 // 允许的源，支持精确匹配、"*"以及带一个通配符的源，如https://*.example.com
+	AllowOriginRegex []string                 // 允许的源的正则表达式
+	AllowOriginFunc  func(origin string) bool // 自定义源校验函数
+	AllowMethods     []string                 // 允许的请求方法，默认为GET、POST、PUT、PATCH、DELETE、HEAD
+	AllowHeaders     []string                 // 允许的请求头，为空时允许预检请求中声明的所有请求头
+	AllowCredentials bool                     // 是否允许携带Cookie等凭证
+	ExposeHeaders    []string                 // 允许浏览器读取的响应头
+	MaxAge           time.Duration            // 预检结果的缓存时间
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead,
+}
+
+// CORS 跨域中间件，预检请求直接返回204；
+// 需要通过Engine.UseMiddleWare注册，这样只注册了Get、Post的路由也能响应OPTIONS预检请求
+func CORS(conf CORSConfig) MiddleWareFunc {
+	allowAll := false
+	var exact []string
+	var wildcards [][2]string
+	for _, origin := range conf.AllowOrigins {
+		switch {
+		case origin == "*":
+			allowAll = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			wildcards = append(wildcards, [2]string{prefix, suffix})
+		default:
+			exact = append(exact, origin)
+		}
+	}
+	regexps := make([]*regexp.Regexp, len(conf.AllowOriginRegex))
+	for i, expr := range conf.AllowOriginRegex {
+		regexps[i] = regexp.MustCompile(expr)
+	}
+	allowOrigin := func(origin string) bool {
+		if allowAll {
+			return true
+		}
+		for _, o := range exact {
+			if strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		for _, w := range wildcards {
+			if len(origin) > len(w[0])+len(w[1]) && strings.HasPrefix(origin, w[0]) && strings.HasSuffix(origin, w[1]) {
+				return true
+			}
+		}
+		for _, r := range regexps {
+			if r.MatchString(origin) {
+				return true
+			}
+		}
+		return conf.AllowOriginFunc != nil && conf.AllowOriginFunc(origin)
+	}
+
+	methods := conf.AllowMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(conf.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(conf.ExposeHeaders, ", ")
+	maxAge := ""
+	if conf.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(conf.MaxAge / time.Second))
+	}
+	// 允许所有源且不携带凭证时返回*，否则回显请求的源
+	anyOrigin := allowAll && !conf.AllowCredentials
+
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			header := ctx.Writer.Header()
+			if !anyOrigin {
+				// 响应随请求的源变化，不允许的源和不带源的请求也要设置，避免共享缓存把它们的响应用于允许的源
+				header.Add("Vary", "Origin")
+			}
+			origin := ctx.Request.Header.Get("Origin")
+			if origin == "" {
+				next(ctx)
+				return
+			}
+			preflight := ctx.Request.Method == http.MethodOptions &&
+				ctx.Request.Header.Get("Access-Control-Request-Method") != ""
+			if !allowOrigin(origin) {
+				if preflight {
+					ctx.AbortWithStatus(http.StatusForbidden)
+					return
+				}
+				next(ctx)
+				return
+			}
+
+			if anyOrigin {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if conf.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				if exposeHeaders != "" {
+					header.Set("Access-Control-Expose-Headers", exposeHeaders)
+				}
+				next(ctx)
+				return
+			}
+
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+			header.Set("Access-Control-Allow-Methods", allowMethods)
+			if allowHeaders != "" {
+				header.Set("Access-Control-Allow-Headers", allowHeaders)
+			} else if requested := ctx.Request.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				header.Set("Access-Control-Allow-Headers", requested)
+			}
+			if maxAge != "" {
+				header.Set("Access-Control-Max-Age", maxAge)
+			}
+			ctx.AbortWithStatus(http.StatusNoContent)
+		}
+	}
+}
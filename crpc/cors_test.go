@@ -0,0 +1,113 @@
+package crpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	engine := MakeEngine()
+	engine.UseMiddleWare(CORS(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.ceer.dev"},
+		AllowOriginRegex: []string{`^http://localhost:\d+$`},
+		AllowOriginFunc:  func(origin string) bool { return origin == "https://partner.com" },
+		AllowHeaders:     []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Request-Id"},
+		MaxAge:           12 * time.Hour,
+	}))
+	engine.CreateGroup("user").Get("/info", func(ctx *Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	request := func(method, origin string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/user/info", nil)
+		req.Header.Set("Origin", origin)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		writer := httptest.NewRecorder()
+		engine.ServeHTTP(writer, req)
+		return writer
+	}
+	preflight := map[string]string{"Access-Control-Request-Method": "GET"}
+
+	for _, origin := range []string{"https://app.example.com", "https://api.ceer.dev", "http://localhost:3000", "https://partner.com"} {
+		writer := request(http.MethodOptions, origin, preflight)
+		header := writer.Header()
+		if writer.Code != http.StatusNoContent || header.Get("Access-Control-Allow-Origin") != origin ||
+			header.Get("Access-Control-Allow-Credentials") != "true" ||
+			header.Get("Access-Control-Allow-Headers") != "Authorization, Content-Type" ||
+			header.Get("Access-Control-Max-Age") != "43200" ||
+			!strings.Contains(header.Get("Access-Control-Allow-Methods"), "GET") {
+			t.Errorf("%s: unexpected preflight response %d %v", origin, writer.Code, header)
+		}
+	}
+	if writer := request(http.MethodOptions, "https://evil.com", preflight); writer.Code != http.StatusForbidden {
+		t.Errorf("disallowed preflight should be rejected, got %d", writer.Code)
+	}
+
+	writer := request(http.MethodGet, "https://app.example.com", nil)
+	if writer.Code != http.StatusOK || writer.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		writer.Header().Get("Access-Control-Expose-Headers") != "X-Request-Id" {
+		t.Errorf("unexpected response %d %v", writer.Code, writer.Header())
+	}
+	writer = request(http.MethodGet, "https://evil.com", nil)
+	if writer.Code != http.StatusOK || writer.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed origin should not get CORS headers: %v", writer.Header())
+	}
+	if vary := writer.Header().Values("Vary"); len(vary) != 1 || vary[0] != "Origin" {
+		t.Errorf("disallowed origin response should vary by origin: %v", vary)
+	}
+	// 通配符至少匹配一个字符
+	if writer := request(http.MethodOptions, "https://.ceer.dev", preflight); writer.Code != http.StatusForbidden {
+		t.Errorf("empty subdomain should be rejected, got %d", writer.Code)
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	engine := MakeEngine()
+	engine.UseMiddleWare(CORS(CORSConfig{AllowOrigins: []string{"*"}}))
+	engine.CreateGroup("user").Post("/login", func(ctx *Context) {})
+	req := httptest.NewRequest(http.MethodOptions, "/user/login", nil)
+	req.Header.Set("Origin", "https://any.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "X-Token")
+	writer := httptest.NewRecorder()
+	engine.ServeHTTP(writer, req)
+	if writer.Code != http.StatusNoContent || writer.Header().Get("Access-Control-Allow-Origin") != "*" ||
+		writer.Header().Get("Access-Control-Allow-Headers") != "X-Token" {
+		t.Errorf("unexpected preflight response %d %v", writer.Code, writer.Header())
+	}
+}
+
+func TestCORSOnGroup(t *testing.T) {
+	engine := MakeEngine()
+	api := engine.CreateGroup("api")
+	api.UseMiddleWare(CORS(CORSConfig{AllowOrigins: []string{"https://app.example.com"}}))
+	api.Post("/orders", func(ctx *Context) {})
+	engine.CreateGroup("admin").Post("/orders", func(ctx *Context) {})
+	preflight := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		writer := httptest.NewRecorder()
+		engine.ServeHTTP(writer, req)
+		return writer
+	}
+
+	// 只注册了POST的路由，预检请求经过组上的CORS中间件
+	writer := preflight("/api/orders")
+	if writer.Code != http.StatusNoContent || writer.Header().Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		!strings.Contains(writer.Header().Get("Access-Control-Allow-Methods"), "POST") {
+		t.Errorf("unexpected preflight response %d %v", writer.Code, writer.Header())
+	}
+	// 其他组不受影响
+	writer = preflight("/admin/orders")
+	if writer.Code != http.StatusNoContent || writer.Header().Get("Access-Control-Allow-Origin") != "" ||
+		writer.Header().Get("Allow") != "OPTIONS, POST" {
+		t.Errorf("unexpected options response %d %v", writer.Code, writer.Header())
+	}
+}
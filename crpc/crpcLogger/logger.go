@@ -39,6 +39,7 @@ type Logger struct {
 	Formatter   LoggerFormatter
 	LogFilePath string
 	LogFileSize int64
+	Fields      map[string]any // 每条日志都会带上的字段，如请求ID
 }
 
 type LoggerWriter struct {
@@ -105,6 +106,19 @@ func NewLogger() *Logger {
 	return &Logger{}
 }
 
+// WithFields 返回一个带有额外字段的Logger，与原Logger共用输出
+func (logger *Logger) WithFields(fields map[string]any) *Logger {
+	l := *logger
+	l.Fields = make(map[string]any, len(logger.Fields)+len(fields))
+	for k, v := range logger.Fields {
+		l.Fields[k] = v
+	}
+	for k, v := range fields {
+		l.Fields[k] = v
+	}
+	return &l
+}
+
 func (logger *Logger) InfoFields(tag string, msg any, fields map[string]any) {
 	logger.Print(LevelInfo, tag, msg, fields)
 }
@@ -134,6 +148,16 @@ func (logger *Logger) Print(level LogLevel, tag string, msg any, fields map[stri
 		// 当前级别大于输入级别，则不打印
 		return
 	}
+	if len(logger.Fields) > 0 {
+		merged := make(map[string]any, len(logger.Fields)+len(fields))
+		for k, v := range logger.Fields {
+			merged[k] = v
+		}
+		for k, v := range fields {
+			merged[k] = v
+		}
+		fields = merged
+	}
 	param := &LoggerFormatterParam{
 		Level:  level,
 		Tag:    tag,
@@ -156,8 +180,8 @@ func (logger *Logger) Print(level LogLevel, tag string, msg any, fields map[stri
 
 // CheckFileSize 检查文件大小
 func (logger *Logger) CheckFileSize(writer *LoggerWriter) {
-	logFile := writer.Out.(*os.File)
-	if logFile != nil {
+	logFile, ok := writer.Out.(*os.File)
+	if ok && logFile != nil {
 		stat, err := logFile.Stat()
 		if err != nil {
 			log.Println(err)
@@ -2,6 +2,8 @@ package crpcLogger
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -26,16 +28,34 @@ func (t *TextFormatter) LevelColor(level LogLevel) string {
 func (t *TextFormatter) Format(param *LoggerFormatterParam) string {
 	now := time.Now()
 	if param.IsColor {
-		return fmt.Sprintf("[crpc] %v | %s%s%s | [ %s ]:%s",
+		return fmt.Sprintf("[crpc] %v | %s%s%s | [ %s ]:%s%s",
 			now.Format("2006/01/02 - 15:04:05"),
 			t.LevelColor(param.Level), param.Level.Level(), reset,
-			param.Tag, param.Msg,
+			param.Tag, param.Msg, formatFields(param.Fields),
 		)
 	} else {
-		return fmt.Sprintf("[crpc] %v | %s | [ %s ]:%s",
+		return fmt.Sprintf("[crpc] %v | %s | [ %s ]:%s%s",
 			now.Format("2006/01/02 - 15:04:05"),
 			param.Level.Level(),
-			param.Tag, param.Msg,
+			param.Tag, param.Msg, formatFields(param.Fields),
 		)
 	}
 }
+
+// 按键排序输出字段，格式为 | key=value key=value
+func formatFields(fields map[string]any) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var builder strings.Builder
+	builder.WriteString(" |")
+	for _, k := range keys {
+		builder.WriteString(fmt.Sprintf(" %s=%v", k, fields[k]))
+	}
+	return builder.String()
+}
@@ -1,25 +1,208 @@
 package crpc
 
 import (
-	"golang.org/x/net/context"
+	"container/list"
+	"context"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github/CeerDecy/RpcFrameWork/crpc/limiter"
 	"golang.org/x/time/rate"
+	"math"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 )
 
+// Limiter 全局限流中间件，所有请求共享一个令牌桶，最多等待1秒；按IP、用户等维度限流使用RateLimit
 func Limiter(limit, cap int) MiddleWareFunc {
-	limiter := rate.NewLimiter(rate.Limit(limit), cap)
+	return LimiterWithConfig(LimiterConfig{
+		Limiter: limiter.NewTokenBucket(rate.Limit(limit), cap),
+		Timeout: time.Second,
+		RejectHandle: func(ctx *Context) {
+			ctx.String(http.StatusForbidden, "被限流了")
+		},
+	})
+}
+
+// LimiterConfig 限流中间件配置
+type LimiterConfig struct {
+	Limiter      limiter.Limiter // 限流器，可使用令牌桶、滑动窗口、并发数等实现
+	Timeout      time.Duration   // 等待许可的最长时间，为0时只受请求上下文控制
+	RejectHandle HandleFunc      // 被限流时的处理函数，默认返回429
+}
+
+// LimiterWithConfig 使用limiter.Limiter限流的中间件，许可在处理链执行完毕后释放
+func LimiterWithConfig(conf LimiterConfig) MiddleWareFunc {
+	if conf.RejectHandle == nil {
+		conf.RejectHandle = defaultRejectHandle
+	}
 	return func(next HandleFunc) HandleFunc {
 		return func(ctx *Context) {
-			timeout, cancelFunc := context.WithTimeout(context.Background(), time.Duration(1)*time.Second)
-			defer cancelFunc()
-			err := limiter.WaitN(timeout, 1)
+			var acquireCtx context.Context = ctx
+			if conf.Timeout > 0 {
+				var cancel context.CancelFunc
+				acquireCtx, cancel = context.WithTimeout(ctx, conf.Timeout)
+				defer cancel()
+			}
+			release, err := conf.Limiter.Acquire(acquireCtx)
 			if err != nil {
-				ctx.String(http.StatusForbidden, "被限流了")
+				ctx.Abort()
+				conf.RejectHandle(ctx)
 				return
 			}
+			defer release()
 			next(ctx)
+		}
+	}
+}
+
+// AdaptiveLimiter 自适应并发限流中间件，超出当前并发上限的请求直接返回429；
+// 可通过l.Limit()获取当前上限用于监控
+func AdaptiveLimiter(l *limiter.Adaptive) MiddleWareFunc {
+	return LimiterWithConfig(LimiterConfig{Limiter: l})
+}
+
+const defaultMaxKeys = 10000
+
+// RateLimitConfig 按键限流配置，每个键拥有独立的令牌桶
+type RateLimitConfig struct {
+	Limit        rate.Limit                // 每秒生成的令牌数
+	Burst        int                       // 令牌桶容量
+	KeyFunc      func(ctx *Context) string // 生成限流键，默认按客户端IP，返回空字符串时不限流
+	MaxKeys      int                       // 最多保存的令牌桶数量，超出时淘汰最久未使用的，默认10000
+	RejectHandle HandleFunc                // 被限流时的处理函数，默认返回429
+}
 
+// RateLimit 按键限流中间件，响应中带有RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset头，
+// 被限流时额外返回Retry-After
+func RateLimit(conf RateLimitConfig) MiddleWareFunc {
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = KeyByIP
+	}
+	if conf.MaxKeys <= 0 {
+		conf.MaxKeys = defaultMaxKeys
+	}
+	if conf.RejectHandle == nil {
+		conf.RejectHandle = defaultRejectHandle
+	}
+	buckets := newBucketCache(conf.MaxKeys, func() *rate.Limiter {
+		return rate.NewLimiter(conf.Limit, conf.Burst)
+	})
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			key := conf.KeyFunc(ctx)
+			if key == "" {
+				next(ctx)
+				return
+			}
+			bucket := buckets.get(key)
+			now := time.Now()
+			reservation := bucket.ReserveN(now, 1)
+			delay := reservation.DelayFrom(now)
+			allowed := reservation.OK() && delay == 0
+			if !allowed {
+				reservation.CancelAt(now)
+			}
+			header := ctx.Writer.Header()
+			tokens := bucket.TokensAt(now)
+			header.Set("RateLimit-Limit", strconv.Itoa(conf.Burst))
+			header.Set("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(tokens)))))
+			header.Set("RateLimit-Reset", strconv.Itoa(resetSeconds(conf.Limit, float64(conf.Burst)-tokens)))
+			if allowed {
+				next(ctx)
+				return
+			}
+			if reservation.OK() {
+				header.Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			}
+			ctx.Abort()
+			conf.RejectHandle(ctx)
 		}
 	}
 }
+
+// 令牌桶补充missing个令牌所需的秒数
+func resetSeconds(limit rate.Limit, missing float64) int {
+	if missing <= 0 || limit <= 0 {
+		return 0
+	}
+	return int(math.Ceil(missing / float64(limit)))
+}
+
+func defaultRejectHandle(ctx *Context) {
+	ctx.String(http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests))
+}
+
+// KeyByIP 按客户端IP限流，经过可信代理时使用转发请求头中的客户端IP，见Engine.SetTrustedProxies
+func KeyByIP(ctx *Context) string {
+	return ctx.ClientIP()
+}
+
+// KeyByHeader 按请求头限流，如API Key
+func KeyByHeader(name string) func(ctx *Context) string {
+	return func(ctx *Context) string {
+		return ctx.Request.Header.Get(name)
+	}
+}
+
+// KeyByClaim 按JWT中的字段限流，如sub，需要在token.AuthInterceptor之后使用
+func KeyByClaim(name string) func(ctx *Context) string {
+	return func(ctx *Context) string {
+		value, ok := ctx.Get("jwt_claims")
+		if !ok {
+			return ""
+		}
+		claims, ok := value.(jwt.MapClaims)
+		if !ok || claims[name] == nil {
+			return ""
+		}
+		return fmt.Sprint(claims[name])
+	}
+}
+
+// KeyByRoute 按路由限流，同一路由的所有请求共享令牌桶
+func KeyByRoute(ctx *Context) string {
+	return ctx.Request.Method + " " + ctx.FullPath()
+}
+
+// 按最近使用顺序淘汰的令牌桶缓存
+type bucketCache struct {
+	mu      sync.Mutex
+	maxKeys int
+	ll      *list.List
+	items   map[string]*list.Element
+	create  func() *rate.Limiter
+}
+
+type bucketEntry struct {
+	key     string
+	limiter *rate.Limiter
+}
+
+func newBucketCache(maxKeys int, create func() *rate.Limiter) *bucketCache {
+	return &bucketCache{
+		maxKeys: maxKeys,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+		create:  create,
+	}
+}
+
+// 获取键对应的令牌桶，不存在时创建，超出容量时淘汰最久未使用的令牌桶
+func (c *bucketCache) get(key string) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.items[key]; ok {
+		c.ll.MoveToFront(element)
+		return element.Value.(*bucketEntry).limiter
+	}
+	entry := &bucketEntry{key: key, limiter: c.create()}
+	c.items[key] = c.ll.PushFront(entry)
+	if c.ll.Len() > c.maxKeys {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*bucketEntry).key)
+	}
+	return entry.limiter
+}
@@ -0,0 +1,122 @@
+package limiter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig 自适应并发限流配置
+type AdaptiveConfig struct {
+	InitialLimit  int // 初始并发上限，默认20
+	MinLimit      int // 并发上限的最小值，默认1
+	MaxLimit      int // 并发上限的最大值，默认1000
+	ProbeInterval int // 每完成多少个请求重新测量一次无排队延迟，默认1000
+}
+
+// Adaptive 基于TCP Vegas算法的自适应并发限流器，
+// 根据请求延迟与无排队延迟（最小延迟）的比值估算排队的请求数，排队少时提高并发上限，排队多时降低
+type Adaptive struct {
+	mu            sync.Mutex
+	limit         float64
+	minLimit      float64
+	maxLimit      float64
+	inFlight      int
+	minRTT        time.Duration // 无排队时的请求延迟
+	samples       int
+	probeInterval int
+	now           func() time.Time
+}
+
+// NewAdaptive 创建自适应并发限流器
+func NewAdaptive(conf AdaptiveConfig) *Adaptive {
+	if conf.InitialLimit <= 0 {
+		conf.InitialLimit = 20
+	}
+	if conf.MinLimit <= 0 {
+		conf.MinLimit = 1
+	}
+	if conf.MaxLimit <= 0 {
+		conf.MaxLimit = 1000
+	}
+	if conf.ProbeInterval <= 0 {
+		conf.ProbeInterval = 1000
+	}
+	return &Adaptive{
+		limit:         float64(conf.InitialLimit),
+		minLimit:      float64(conf.MinLimit),
+		maxLimit:      float64(conf.MaxLimit),
+		probeInterval: conf.ProbeInterval,
+		now:           time.Now,
+	}
+}
+
+// Acquire 正在执行的请求数未达到当前上限时放行，否则立即返回ErrLimited，ctx已结束时返回ctx.Err()；
+// release时以请求耗时作为延迟样本调整上限
+func (a *Adaptive) Acquire(ctx context.Context) (func(), error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.inFlight >= int(a.limit) {
+		return nil, ErrLimited
+	}
+	a.inFlight++
+	inFlight := a.inFlight
+	start := a.now()
+	return func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.inFlight--
+		a.update(a.now().Sub(start), inFlight)
+	}, nil
+}
+
+// 根据一次请求的延迟调整并发上限
+func (a *Adaptive) update(rtt time.Duration, inFlight int) {
+	if rtt <= 0 {
+		return
+	}
+	a.samples++
+	if a.samples >= a.probeInterval {
+		// 定期重新测量，避免下游扩容后仍使用过时的最小延迟
+		a.samples = 0
+		a.minRTT = 0
+	}
+	if a.minRTT == 0 || rtt < a.minRTT {
+		a.minRTT = rtt
+		return
+	}
+	// 并发远未达到上限时延迟不能反映容量，不调整
+	if float64(inFlight)*2 < a.limit {
+		return
+	}
+	queue := math.Ceil(a.limit * (1 - float64(a.minRTT)/float64(rtt)))
+	step := math.Max(1, math.Log10(a.limit))
+	alpha, beta := 3*step, 6*step
+	switch {
+	case queue <= step:
+		a.limit += beta
+	case queue < alpha:
+		a.limit += step
+	case queue > beta:
+		a.limit -= step
+	}
+	a.limit = math.Min(a.maxLimit, math.Max(a.minLimit, a.limit))
+}
+
+// Limit 当前的并发上限
+func (a *Adaptive) Limit() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.limit)
+}
+
+// InFlight 当前正在执行的请求数
+func (a *Adaptive) InFlight() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.inFlight
+}
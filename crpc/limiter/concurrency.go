@@ -0,0 +1,37 @@
+package limiter
+
+import "context"
+
+// Concurrency 并发数限流器（舱壁），同时执行的请求数不超过上限
+type Concurrency struct {
+	sem chan struct{}
+}
+
+// NewConcurrency 创建并发数限流器，最多允许max个请求同时执行
+func NewConcurrency(max int) *Concurrency {
+	return &Concurrency{sem: make(chan struct{}, max)}
+}
+
+// Acquire 有空闲名额时立即放行，否则等待其他请求完成，直到ctx结束
+func (c *Concurrency) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case c.sem <- struct{}{}:
+		return c.release, nil
+	default:
+	}
+	select {
+	case c.sem <- struct{}{}:
+		return c.release, nil
+	case <-ctx.Done():
+		return nil, ErrLimited
+	}
+}
+
+func (c *Concurrency) release() {
+	<-c.sem
+}
+
+// InFlight 当前正在执行的请求数
+func (c *Concurrency) InFlight() int {
+	return len(c.sem)
+}
@@ -0,0 +1,40 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/time/rate"
+)
+
+// ErrLimited 请求被限流
+var ErrLimited = errors.New("limiter: request limited")
+
+// Limiter 限流器，HTTP中间件与TcpRpcServer共用
+type Limiter interface {
+	// Acquire 获取一次执行许可，获取成功时需要在请求处理完成后调用release；
+	// 被限流或等待期间ctx结束时返回ErrLimited
+	Acquire(ctx context.Context) (release func(), err error)
+}
+
+func noopRelease() {}
+
+// TokenBucket 令牌桶限流器
+type TokenBucket struct {
+	limiter *rate.Limiter
+}
+
+// NewTokenBucket 创建令牌桶限流器，limit为每秒生成的令牌数，burst为令牌桶容量
+func NewTokenBucket(limit rate.Limit, burst int) *TokenBucket {
+	return &TokenBucket{limiter: rate.NewLimiter(limit, burst)}
+}
+
+// Acquire 获取令牌，令牌不足时等待，直到ctx结束
+func (b *TokenBucket) Acquire(ctx context.Context) (func(), error) {
+	if b.limiter.Allow() {
+		return noopRelease, nil
+	}
+	if err := b.limiter.Wait(ctx); err != nil {
+		return nil, ErrLimited
+	}
+	return noopRelease, nil
+}
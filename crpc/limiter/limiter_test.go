@@ -0,0 +1,123 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := NewTokenBucket(1, 1)
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := bucket.Acquire(expired); err != nil {
+		t.Errorf("available token should be granted without waiting, got %v", err)
+	}
+	if _, err := bucket.Acquire(expired); err != ErrLimited {
+		t.Errorf("expected ErrLimited, got %v", err)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	now := time.Unix(1000, 0)
+	window := NewSlidingWindow(4, time.Second)
+	window.now = func() time.Time { return now }
+	acquire := func() bool {
+		_, err := window.Acquire(context.Background())
+		return err == nil
+	}
+	for i := 0; i < 4; i++ {
+		if !acquire() {
+			t.Fatalf("request %d should be allowed", i)
+		}
+	}
+	if acquire() {
+		t.Error("fifth request in the window should be limited")
+	}
+	// 进入下一个窗口的一半，上一个窗口的4个请求按一半计入
+	now = now.Add(1500 * time.Millisecond)
+	if !acquire() || !acquire() || acquire() {
+		t.Error("only two requests should be allowed half way into the next window")
+	}
+	// 跨过多个窗口后计数清零
+	now = now.Add(3 * time.Second)
+	for i := 0; i < 4; i++ {
+		if !acquire() {
+			t.Fatalf("request %d should be allowed after idle windows", i)
+		}
+	}
+}
+
+func TestConcurrency(t *testing.T) {
+	bulkhead := NewConcurrency(2)
+	release1, _ := bulkhead.Acquire(context.Background())
+	release2, _ := bulkhead.Acquire(context.Background())
+	if bulkhead.InFlight() != 2 {
+		t.Errorf("unexpected in-flight %d", bulkhead.InFlight())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := bulkhead.Acquire(ctx); err != ErrLimited {
+		t.Errorf("expected ErrLimited, got %v", err)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release1()
+	}()
+	release3, err := bulkhead.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("waiting request should be granted after release, got %v", err)
+	}
+	release2()
+	release3()
+	if bulkhead.InFlight() != 0 {
+		t.Errorf("unexpected in-flight %d", bulkhead.InFlight())
+	}
+}
+
+func TestAdaptive(t *testing.T) {
+	now := time.Unix(1000, 0)
+	adaptive := NewAdaptive(AdaptiveConfig{InitialLimit: 10, MaxLimit: 50})
+	adaptive.now = func() time.Time { return now }
+	// 以满并发执行一轮请求，每个请求耗时latency
+	round := func(latency time.Duration) {
+		var releases []func()
+		for {
+			release, err := adaptive.Acquire(context.Background())
+			if err != nil {
+				break
+			}
+			releases = append(releases, release)
+		}
+		if len(releases) != adaptive.Limit() {
+			t.Fatalf("acquired %d permits with limit %d", len(releases), adaptive.Limit())
+		}
+		now = now.Add(latency)
+		for _, release := range releases {
+			release()
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		round(10 * time.Millisecond)
+	}
+	grown := adaptive.Limit()
+	if grown <= 10 || grown > 50 {
+		t.Errorf("limit should grow when latency is stable, got %d", grown)
+	}
+	for i := 0; i < 5; i++ {
+		round(40 * time.Millisecond)
+	}
+	if adaptive.Limit() >= grown {
+		t.Errorf("limit should shrink when latency increases, got %d (was %d)", adaptive.Limit(), grown)
+	}
+	if adaptive.InFlight() != 0 {
+		t.Errorf("unexpected in-flight %d", adaptive.InFlight())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := adaptive.Acquire(ctx); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
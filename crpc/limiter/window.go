@@ -0,0 +1,48 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SlidingWindow 滑动窗口计数限流器，按上一个窗口的计数加权估算当前窗口内的请求数，
+// 避免固定窗口在边界处放过两倍的请求
+type SlidingWindow struct {
+	mu       sync.Mutex
+	limit    int
+	window   time.Duration
+	start    time.Time // 当前窗口的开始时间
+	current  int       // 当前窗口的请求数
+	previous int       // 上一个窗口的请求数
+	now      func() time.Time
+}
+
+// NewSlidingWindow 创建滑动窗口限流器，每个window内最多允许limit个请求
+func NewSlidingWindow(limit int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{limit: limit, window: window, now: time.Now}
+}
+
+// Acquire 窗口内的请求数未超出限制时放行，否则立即返回ErrLimited
+func (w *SlidingWindow) Acquire(context.Context) (func(), error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	now := w.now()
+	if elapsed := now.Sub(w.start); elapsed >= w.window {
+		// 进入下一个窗口时当前计数成为上一个窗口的计数，跨过多个窗口时全部清零
+		if elapsed < 2*w.window {
+			w.previous = w.current
+			w.start = w.start.Add(w.window)
+		} else {
+			w.previous = 0
+			w.start = now
+		}
+		w.current = 0
+	}
+	weight := 1 - float64(now.Sub(w.start))/float64(w.window)
+	if float64(w.previous)*weight+float64(w.current) >= float64(w.limit) {
+		return nil, ErrLimited
+	}
+	w.current++
+	return noopRelease, nil
+}
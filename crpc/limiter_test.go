@@ -0,0 +1,100 @@
+package crpc
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"github/CeerDecy/RpcFrameWork/crpc/limiter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	engine := MakeEngine()
+	engine.UseMiddleWare(RateLimit(RateLimitConfig{Limit: 1, Burst: 2, MaxKeys: 2}))
+	engine.CreateGroup("user").Get("/info", func(ctx *Context) {
+		ctx.String(http.StatusOK, "ok")
+	})
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/user/info", nil)
+		req.RemoteAddr = ip + ":1234"
+		writer := httptest.NewRecorder()
+		engine.ServeHTTP(writer, req)
+		return writer
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		writer := request("10.0.0.1")
+		if writer.Code != http.StatusOK || writer.Header().Get("RateLimit-Remaining") != remaining ||
+			writer.Header().Get("RateLimit-Limit") != "2" {
+			t.Errorf("request %d: unexpected response %d %v", i, writer.Code, writer.Header())
+		}
+	}
+	writer := request("10.0.0.1")
+	if writer.Code != http.StatusTooManyRequests || writer.Header().Get("Retry-After") != "1" ||
+		writer.Header().Get("RateLimit-Reset") != "2" {
+		t.Errorf("expected 429, got %d %v", writer.Code, writer.Header())
+	}
+	if writer = request("10.0.0.2"); writer.Code != http.StatusOK {
+		t.Errorf("other keys should not be limited, got %d", writer.Code)
+	}
+	// 超出MaxKeys后最久未使用的令牌桶被淘汰，重新获得完整的令牌
+	request("10.0.0.3")
+	if writer = request("10.0.0.1"); writer.Code != http.StatusOK {
+		t.Errorf("evicted bucket should be recreated, got %d", writer.Code)
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	var rejected int
+	engine := MakeEngine()
+	group := engine.CreateGroup("user")
+	group.UseMiddleWare(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.Set("jwt_claims", jwt.MapClaims{"sub": ctx.Request.Header.Get("X-User")})
+			next(ctx)
+		}
+	}, RateLimit(RateLimitConfig{
+		Limit:        1,
+		Burst:        1,
+		KeyFunc:      KeyByClaim("sub"),
+		RejectHandle: func(ctx *Context) { rejected++; ctx.Fail(http.StatusTooManyRequests, "slow down") },
+	}))
+	group.Get("/:id", func(ctx *Context) {})
+
+	for i, user := range []string{"ceer", "decy", "ceer"} {
+		req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+		req.Header.Set("X-User", user)
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		if want := i / 2; rejected != want {
+			t.Errorf("request %d: rejected %d, want %d", i, rejected, want)
+		}
+	}
+	ctx := &Context{Request: httptest.NewRequest(http.MethodGet, "/user/1", nil), fullPath: "/user/:id"}
+	if key := KeyByRoute(ctx); key != "GET /user/:id" {
+		t.Errorf("unexpected route key %q", key)
+	}
+}
+
+func TestLimiterWithConfig(t *testing.T) {
+	engine := MakeEngine()
+	bulkhead := limiter.NewConcurrency(1)
+	engine.UseMiddleWare(LimiterWithConfig(LimiterConfig{Limiter: bulkhead, Timeout: 10 * time.Millisecond}))
+	group := engine.CreateGroup("user")
+	group.Get("/nested", func(ctx *Context) {
+		// 处理期间名额被占用，同时到达的请求被限流
+		inner := serve(engine, http.MethodGet, "/user/info")
+		ctx.String(http.StatusOK, "%d", inner.Code)
+	})
+	group.Get("/info", func(ctx *Context) {})
+	writer := serve(engine, http.MethodGet, "/user/nested")
+	if writer.Body.String() != "429" {
+		t.Errorf("concurrent request should be rejected, got %q", writer.Body.String())
+	}
+	if bulkhead.InFlight() != 0 {
+		t.Error("permit should be released after the handler returns")
+	}
+	if writer = serve(engine, http.MethodGet, "/user/info"); writer.Code != http.StatusOK {
+		t.Errorf("unexpected status %d", writer.Code)
+	}
+}
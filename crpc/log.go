@@ -6,7 +6,6 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 )
 
@@ -44,22 +43,31 @@ var defaultFormatter = func(params *LogFormatterParams) string {
 	if params.Latency > time.Minute {
 		params.Latency = params.Latency.Truncate(time.Second)
 	}
+	var requestID, timeout string
+	if params.RequestID != "" {
+		requestID = " | " + params.RequestID
+	}
+	if params.Timeout {
+		timeout = " | timeout"
+	}
 	if params.IsDisplayColor {
-		return fmt.Sprintf("[crpc] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v",
+		return fmt.Sprintf("[crpc] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v%s%s",
 			params.TimeStamp.Format("2006/01/02 - 15:04:05"),
 			codeColor, params.Code, resetColor,
 			params.Latency, params.ClientIP,
 			methodColor, params.Method, resetColor,
-			params.Path,
+			params.Path, requestID, timeout,
 		)
 	} else {
-		return fmt.Sprintf("[crpc] %v | %3d | %13v | %15s | %-7s %#v",
+		return fmt.Sprintf("[crpc] %v | %3d | %13v | %15s | %-7s %#v%s%s",
 			params.TimeStamp.Format("2006/01/02 - 15:04:05"),
 			params.Code,
 			params.Latency,
 			params.ClientIP,
 			params.Method,
 			params.Path,
+			requestID,
+			timeout,
 		)
 	}
 
@@ -74,6 +82,8 @@ type LogFormatterParams struct {
 	Method         string
 	Path           string
 	IsDisplayColor bool
+	Timeout        bool   // 请求是否被Timeout中间件判定为超时
+	RequestID      string // RequestID中间件生成或沿用的请求ID
 }
 
 func (p *LogFormatterParams) StateCodeColor() string {
@@ -124,8 +134,7 @@ func LoggingWithConfig(conf LoggingConfig, next HandleFunc) HandleFunc {
 		next(ctx)
 		end := time.Now()
 		latency := end.Sub(start)
-		ip, _, _ := net.SplitHostPort(strings.TrimSpace(ctx.Request.RemoteAddr))
-		clientIP := net.ParseIP(ip)
+		clientIP := net.ParseIP(ctx.ClientIP())
 		method := ctx.Request.Method
 
 		if raw != "" {
@@ -134,12 +143,14 @@ func LoggingWithConfig(conf LoggingConfig, next HandleFunc) HandleFunc {
 		params := &LogFormatterParams{
 			ctx.Request,
 			end,
-			ctx.code,
+			ctx.Status(),
 			latency,
 			clientIP,
 			method,
 			path,
 			true,
+			ctx.IsTimeout(),
+			ctx.RequestID(),
 		}
 		_, _ = fmt.Fprintln(out, formatter(params))
 	}
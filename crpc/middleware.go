@@ -0,0 +1,50 @@
+package crpc
+
+// 使用中间件列表包装处理函数，先添加的中间件位于外层，先执行
+func chain(handleFunc HandleFunc, middleWares []MiddleWareFunc) HandleFunc {
+	for i := len(middleWares) - 1; i >= 0; i-- {
+		handleFunc = wrapMiddleWare(middleWares[i], handleFunc)
+	}
+	return handleFunc
+}
+
+// 包装单个中间件
+// 请求被Abort之后next不再执行；中间件中既可以调用next(ctx)，也可以调用ctx.Next()，next最多执行一次，
+// 每次执行中间件时在ctx.nextRan中压入一个标记，记录本次执行中next是否已经执行过
+func wrapMiddleWare(middleWare MiddleWareFunc, next HandleFunc) HandleFunc {
+	guard := func(ctx *Context) {
+		ctx.next = nil
+		// 在Copy出的上下文中执行（如Timeout中间件）时没有标记，不做限制
+		if top := len(ctx.nextRan) - 1; top >= 0 {
+			if ctx.nextRan[top] {
+				return
+			}
+			ctx.nextRan[top] = true
+		}
+		if !ctx.aborted {
+			next(ctx)
+		}
+	}
+	handleFunc := middleWare(guard)
+	return func(ctx *Context) {
+		prev := ctx.next
+		depth := len(ctx.nextRan)
+		ctx.next = guard
+		ctx.nextRan = append(ctx.nextRan, false)
+		handleFunc(ctx)
+		ctx.nextRan = ctx.nextRan[:depth]
+		ctx.next = prev
+	}
+}
+
+// WrapHandle 将处理函数转换为中间件
+// 处理函数中调用ctx.Next()执行后续处理链，Next之后的代码可以看到最终的状态码和响应大小；
+// 未调用ctx.Next()且没有Abort时，后续处理链在处理函数返回后自动执行
+func WrapHandle(handleFunc HandleFunc) MiddleWareFunc {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			handleFunc(ctx)
+			ctx.Next()
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package crpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// 记录执行顺序的中间件
+func traceMiddleWare(trace *[]string, name string) MiddleWareFunc {
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			*trace = append(*trace, name+" before")
+			next(ctx)
+			*trace = append(*trace, name+" after")
+		}
+	}
+}
+
+func TestMiddleWareOrder(t *testing.T) {
+	var trace []string
+	engine := MakeEngine()
+	api := engine.CreateGroup("api")
+	v1 := api.Group("v1")
+	v1.Get("/user", func(ctx *Context) {
+		trace = append(trace, "handler")
+	}, traceMiddleWare(&trace, "route1"), traceMiddleWare(&trace, "route2"))
+	v1.UseMiddleWare(traceMiddleWare(&trace, "v1"))
+	api.UseMiddleWare(traceMiddleWare(&trace, "api"))
+	engine.UseMiddleWare(traceMiddleWare(&trace, "global1"), traceMiddleWare(&trace, "global2"))
+	v1.PostMiddleWare(func(ctx *Context) { trace = append(trace, "v1 post") })
+	engine.PostMiddleWare(func(ctx *Context) { trace = append(trace, "global post") })
+
+	serve(engine, http.MethodGet, "/api/v1/user")
+	expected := []string{
+		"global1 before", "global2 before", "api before", "v1 before", "route1 before", "route2 before",
+		"handler",
+		"route2 after", "route1 after", "v1 after", "api after", "global2 after", "global1 after",
+		"v1 post", "global post",
+	}
+	if !reflect.DeepEqual(trace, expected) {
+		t.Errorf("unexpected order\nexpected %v\ngot      %v", expected, trace)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	var trace []string
+	var status, size int
+	engine := MakeEngine()
+	group := engine.CreateGroup("user")
+	group.UseMiddleWare(traceMiddleWare(&trace, "outer"), func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, map[string]any{"msg": "un authorized"})
+			next(ctx)
+		}
+	}, traceMiddleWare(&trace, "inner"))
+	group.PostMiddleWare(func(ctx *Context) {
+		status, size = ctx.Status(), ctx.Size()
+	})
+	group.Get("/info", func(ctx *Context) {
+		trace = append(trace, "handler")
+	})
+
+	writer := serve(engine, http.MethodGet, "/user/info")
+	if writer.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", writer.Code)
+	}
+	if !reflect.DeepEqual(trace, []string{"outer before", "outer after"}) {
+		t.Errorf("unexpected trace %v", trace)
+	}
+	if status != http.StatusUnauthorized || size != writer.Body.Len() {
+		t.Errorf("post middleware saw status %d size %d", status, size)
+	}
+}
+
+func TestContextNext(t *testing.T) {
+	var trace []string
+	var status, size int
+	engine := MakeEngine()
+	engine.UseMiddleWare(WrapHandle(func(ctx *Context) {
+		trace = append(trace, "before")
+		ctx.Next()
+		ctx.Next()
+		status, size = ctx.Status(), ctx.Size()
+	}), WrapHandle(func(ctx *Context) {
+		trace = append(trace, "no next")
+	}), func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			ctx.Next()
+		}
+	})
+	engine.CreateGroup("user").Get("/info", func(ctx *Context) {
+		trace = append(trace, "handler")
+		ctx.Next()
+		ctx.String(http.StatusCreated, "created")
+	})
+
+	serve(engine, http.MethodGet, "/user/info")
+	if !reflect.DeepEqual(trace, []string{"before", "no next", "handler"}) {
+		t.Errorf("unexpected trace %v", trace)
+	}
+	if status != http.StatusCreated || size != len("created") {
+		t.Errorf("unexpected status %d size %d", status, size)
+	}
+}
+
+func TestNextRunsOnce(t *testing.T) {
+	var trace []string
+	engine := MakeEngine()
+	engine.UseMiddleWare(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.Next()
+			next(ctx)
+			next(ctx)
+			trace = append(trace, "outer")
+		}
+	}, func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			next(ctx)
+			ctx.Next()
+			trace = append(trace, "inner")
+		}
+	})
+	engine.CreateGroup("user").Get("/info", func(ctx *Context) {
+		trace = append(trace, "handler")
+	})
+
+	serve(engine, http.MethodGet, "/user/info")
+	serve(engine, http.MethodGet, "/user/info")
+	want := []string{"handler", "inner", "outer", "handler", "inner", "outer"}
+	if !reflect.DeepEqual(trace, want) {
+		t.Errorf("unexpected trace %v", trace)
+	}
+}
+
+// 不做任何事的中间件，用于测试处理链本身的开销
+func emptyMiddleWare(next HandleFunc) HandleFunc {
+	return func(ctx *Context) {
+		next(ctx)
+	}
+}
+
+// 5个中间件：2个全局、2个组级别、1个路由级别
+func benchMiddleWareEngine() (*Engine, *route) {
+	engine := MakeEngine()
+	engine.UseMiddleWare(emptyMiddleWare, emptyMiddleWare)
+	group := engine.CreateGroup("user")
+	group.UseMiddleWare(emptyMiddleWare, emptyMiddleWare)
+	r := group.Get("/info", func(ctx *Context) {}, emptyMiddleWare)
+	return engine, r.(*route)
+}
+
+// 每次请求重新组合处理链（预先组合之前的做法）
+func BenchmarkMiddleWareChainPerRequest(b *testing.B) {
+	_, r := benchMiddleWareEngine()
+	ctx := &Context{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.group.combineHandlers(r.handleFunc, r.middleWares)(ctx)
+	}
+}
+
+// 使用注册时组合好的处理链
+func BenchmarkMiddleWareChainPrecomputed(b *testing.B) {
+	_, r := benchMiddleWareEngine()
+	ctx := &Context{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r.handler(ctx)
+	}
+}
+
+func BenchmarkMiddleWareServeHTTP(b *testing.B) {
+	engine, _ := benchMiddleWareEngine()
+	request := httptest.NewRequest(http.MethodGet, "/user/info", nil)
+	writer := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(writer, request)
+	}
+}
+
+func TestMiddleWareChainAllocs(t *testing.T) {
+	engine, _ := benchMiddleWareEngine()
+	request := httptest.NewRequest(http.MethodGet, "/user/info", nil)
+	writer := httptest.NewRecorder()
+	engine.ServeHTTP(writer, request)
+	if allocs := testing.AllocsPerRun(100, func() { engine.ServeHTTP(writer, request) }); allocs != 0 {
+		t.Errorf("expected zero allocations, got %v", allocs)
+	}
+}
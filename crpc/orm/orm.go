@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -31,6 +32,7 @@ type CrSession struct {
 	updateParam strings.Builder
 	whereParam  strings.Builder
 	whereValue  []any
+	ctx         context.Context // 执行语句时使用的上下文，可传入*crpc.Context使请求取消时一并取消查询
 }
 
 func (c *CrDB) Close() error {
@@ -80,6 +82,19 @@ func (c *CrDB) NewSession() *CrSession {
 	}
 }
 
+// WithContext 设置会话执行语句时使用的上下文
+func (session *CrSession) WithContext(ctx context.Context) *CrSession {
+	session.ctx = ctx
+	return session
+}
+
+func (session *CrSession) context() context.Context {
+	if session.ctx == nil {
+		return context.Background()
+	}
+	return session.ctx
+}
+
 func (session *CrSession) Table(name string) *CrSession {
 	session.TableName = name
 	return session
@@ -92,11 +107,11 @@ func (session *CrSession) Insert(data any) (int64, int64, error) {
 		strings.Join(session.FieldName, ","),
 		strings.Join(session.placeHolder, ","))
 	session.db.logger.Info("["+session.TableName+"]", query)
-	stmt, err := session.db.db.Prepare(query)
+	stmt, err := session.db.db.PrepareContext(session.context(), query)
 	if err != nil {
 		return -1, -1, err
 	}
-	r, err := stmt.Exec(session.values...)
+	r, err := stmt.ExecContext(session.context(), session.values...)
 	if err != nil {
 		return -1, -1, err
 	}
@@ -165,11 +180,11 @@ func (session *CrSession) InsertBatch(data []any) (int64, int64, error) {
 	}
 	session.batchValue(data)
 	session.db.logger.Info("["+session.TableName+"]", builder.String())
-	stmt, err := session.db.db.Prepare(builder.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), builder.String())
 	if err != nil {
 		return -1, -1, err
 	}
-	r, err := stmt.Exec(session.values...)
+	r, err := stmt.ExecContext(session.context(), session.values...)
 	if err != nil {
 		return -1, -1, err
 	}
@@ -319,12 +334,12 @@ func (session *CrSession) Update(data ...any) (int64, int64, error) {
 	builder.WriteString(query)
 	builder.WriteString(session.whereParam.String())
 	session.db.logger.Info("Update", builder.String())
-	stmt, err := session.db.db.Prepare(builder.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), builder.String())
 	if err != nil {
 		return -1, -1, err
 	}
 	session.values = append(session.values, session.whereValue...)
-	r, err := stmt.Exec(session.values...)
+	r, err := stmt.ExecContext(session.context(), session.values...)
 	if err != nil {
 		return -1, -1, err
 	}
@@ -396,11 +411,11 @@ func (session *CrSession) SelectOne(data any, fields ...string) error {
 	builder.WriteString(query)
 	builder.WriteString(session.whereParam.String())
 	session.db.logger.Info("SelectOne", builder.String())
-	stmt, err := session.db.db.Prepare(builder.String())
+	stmt, err := session.db.db.PrepareContext(session.context(), builder.String())
 	if err != nil {
 		return err
 	}
-	row, err := stmt.Query(session.whereValue...)
+	row, err := stmt.QueryContext(session.context(), session.whereValue...)
 	if err != nil {
 		return err
 	}
@@ -450,11 +465,11 @@ func (session *CrSession) Count() (int64, error) {
 	var builder strings.Builder
 	builder.WriteString(query)
 	builder.WriteString(session.whereParam.String())
-	stmt, err := session.db.db.Prepare(query)
+	stmt, err := session.db.db.PrepareContext(session.context(), query)
 	if err != nil {
 		return -1, err
 	}
-	row := stmt.QueryRow(session.whereValue...)
+	row := stmt.QueryRowContext(session.context(), session.whereValue...)
 	if row.Err() != nil {
 		return -1, row.Err()
 	}
@@ -467,11 +482,11 @@ func (session *CrSession) Count() (int64, error) {
 }
 
 func (session *CrSession) Exec(sql string, values ...any) (int64, error) {
-	stmt, err := session.db.db.Prepare(sql)
+	stmt, err := session.db.db.PrepareContext(session.context(), sql)
 	if err != nil {
 		return 0, err
 	}
-	result, err := stmt.Exec(values...)
+	result, err := stmt.ExecContext(session.context(), values...)
 	if err != nil {
 		return 0, err
 	}
@@ -65,3 +65,14 @@ func GetInstance(client naming_client.INamingClient, serviceName string) (string
 	}
 	return instance.Ip, instance.Port, nil
 }
+
+// DeregisService 注销服务
+func DeregisService(client naming_client.INamingClient, serviceName string, host string, port uint64) error {
+	_, err := client.DeregisterInstance(vo.DeregisterInstanceParam{
+		Ip:          host,
+		Port:        port,
+		ServiceName: serviceName,
+		Ephemeral:   true,
+	})
+	return err
+}
@@ -0,0 +1,79 @@
+package crpc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github/CeerDecy/RpcFrameWork/crpc/rpc"
+)
+
+// RequestIDKey 请求ID在Context.Keys中的键
+const RequestIDKey = "request_id"
+
+// RequestIDHeader 默认的请求ID请求头
+const RequestIDHeader = "X-Request-ID"
+
+// 允许接收的请求ID的最大长度
+const maxRequestIDLength = 128
+
+// RequestIDConfig 请求ID中间件配置
+type RequestIDConfig struct {
+	Header    string        // 读取和返回请求ID的请求头，默认为X-Request-ID
+	Generator func() string // 请求中没有合法的请求ID时用于生成，默认为32位随机十六进制字符串
+}
+
+// RequestIDWithConfig 请求ID中间件，沿用请求头中的请求ID或生成新的请求ID，
+// 保存到Context中并写入响应头，之后ctx.Logger输出的日志都会带上request_id字段；
+// 请求ID同时作为链路追踪ID通过rpc.WithTraceId放入请求的上下文，将*Context传给TcpClientProxy.Call时会自动携带
+func RequestIDWithConfig(conf RequestIDConfig, next HandleFunc) HandleFunc {
+	header := conf.Header
+	if header == "" {
+		header = RequestIDHeader
+	}
+	generator := conf.Generator
+	if generator == nil {
+		generator = generateRequestID
+	}
+	return func(ctx *Context) {
+		id := ctx.Request.Header.Get(header)
+		if !validRequestID(id) {
+			id = generator()
+		}
+		ctx.Set(RequestIDKey, id)
+		ctx.Request = ctx.Request.WithContext(rpc.WithTraceId(ctx.Request.Context(), id))
+		ctx.Writer.Header().Set(header, id)
+		if ctx.Logger != nil {
+			ctx.Logger = ctx.Logger.WithFields(map[string]any{RequestIDKey: id})
+		}
+		next(ctx)
+	}
+}
+
+func RequestID(next HandleFunc) HandleFunc {
+	return RequestIDWithConfig(RequestIDConfig{}, next)
+}
+
+// RequestID 获取请求ID，未使用RequestID中间件时为空
+func (c *Context) RequestID() string {
+	value, _ := c.Get(RequestIDKey)
+	id, _ := value.(string)
+	return id
+}
+
+func generateRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// 只接收由可见ASCII字符组成的请求ID，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
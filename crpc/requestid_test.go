@@ -0,0 +1,50 @@
+package crpc
+
+import (
+	"bytes"
+	"github/CeerDecy/RpcFrameWork/crpc/crpcLogger"
+	"github/CeerDecy/RpcFrameWork/crpc/rpc"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var logOut, accessOut bytes.Buffer
+	engine := MakeEngine()
+	engine.Logger = crpcLogger.TextLogger()
+	engine.Logger.Writers = nil
+	engine.Logger.AddWriter(&logOut, -1)
+	engine.UseMiddleWare(func(next HandleFunc) HandleFunc {
+		return LoggingWithConfig(LoggingConfig{out: &accessOut}, next)
+	}, RequestID)
+	engine.CreateGroup("user").Get("/info", func(ctx *Context) {
+		ctx.Logger.Info("User", "find user")
+		ctx.String(http.StatusOK, rpc.TraceIdFromContext(ctx))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/user/info", nil)
+	req.Header.Set(RequestIDHeader, "gateway-1001")
+	writer := httptest.NewRecorder()
+	engine.ServeHTTP(writer, req)
+	if writer.Header().Get(RequestIDHeader) != "gateway-1001" || writer.Body.String() != "gateway-1001" {
+		t.Errorf("incoming request id should be kept, got %v %q", writer.Header(), writer.Body.String())
+	}
+	if !strings.Contains(logOut.String(), "request_id=gateway-1001") {
+		t.Errorf("handler log should contain the request id: %q", logOut.String())
+	}
+	if !strings.Contains(accessOut.String(), "gateway-1001") {
+		t.Errorf("access log should contain the request id: %q", accessOut.String())
+	}
+
+	for _, incoming := range []string{"", "bad id\n", strings.Repeat("x", 200)} {
+		req = httptest.NewRequest(http.MethodGet, "/user/info", nil)
+		req.Header.Set(RequestIDHeader, incoming)
+		writer = httptest.NewRecorder()
+		engine.ServeHTTP(writer, req)
+		if id := writer.Header().Get(RequestIDHeader); len(id) != 32 || id != writer.Body.String() {
+			t.Errorf("%q: expected a generated request id, got %q", incoming, id)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package crpc
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter 在http.ResponseWriter的基础上记录状态码、响应大小以及响应头是否已经写出
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+
+	// Status 获取响应状态码，尚未写出时为200
+	Status() int
+	// Size 获取已写入的响应体大小
+	Size() int
+	// Written 响应头是否已经写出
+	Written() bool
+	// WriteHeaderNow 立即写出响应头
+	WriteHeaderNow()
+}
+
+// ErrContextCopied 通过Context.Copy得到的副本不能写响应
+var ErrContextCopied = errors.New("crpc: cannot write response with a copied context")
+
+// 副本上下文使用的ResponseWriter，写入均会失败
+type copiedResponseWriter struct {
+	header http.Header
+}
+
+func (w *copiedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *copiedResponseWriter) Write([]byte) (int, error) {
+	return 0, ErrContextCopied
+}
+
+func (w *copiedResponseWriter) WriteHeader(int) {}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status  int
+	size    int
+	written bool
+}
+
+var _ ResponseWriter = &responseWriter{}
+
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.status = http.StatusOK
+	w.size = 0
+	w.written = false
+}
+
+// WriteHeader 写出响应头，响应头只会写出一次，之后的调用会被忽略
+func (w *responseWriter) WriteHeader(code int) {
+	if w.written {
+		return
+	}
+	w.status = code
+	w.WriteHeaderNow()
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.written {
+		w.written = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.written
+}
+
+// Flush 实现http.Flusher，底层不支持时只写出响应头
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack 实现http.Hijacker，接管连接后响应视为已写出
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the ResponseWriter doesn't support the Hijacker interface")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.written = true
+	}
+	return conn, rw, err
+}
+
+// Push 实现http.Pusher，底层不支持HTTP/2推送时返回http.ErrNotSupported
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// 用于HEAD请求的ResponseWriter，保留响应头和状态码，丢弃响应体
+type headResponseWriter struct {
+	ResponseWriter
+}
+
+func (w *headResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	return len(data), nil
+}
@@ -0,0 +1,100 @@
+package crpc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	var status, size int
+	var written bool
+	engine := MakeEngine()
+	engine.PostMiddleWare(func(ctx *Context) {
+		status, size, written = ctx.Status(), ctx.Size(), ctx.Written()
+	})
+	group := engine.CreateGroup("user")
+	group.Get("/fprintf", func(ctx *Context) {
+		_, _ = fmt.Fprintf(ctx.Writer, "%s hello", "CeerDecy")
+	})
+	group.Get("/file", func(ctx *Context) {
+		ctx.File("response_writer_test.go")
+	})
+	group.Get("/missing", func(ctx *Context) {
+		http.ServeFile(ctx.Writer, ctx.Request, "not_exist.file")
+	})
+	group.Get("/empty", func(ctx *Context) {})
+	group.Get("/twice", func(ctx *Context) {
+		ctx.Writer.WriteHeader(http.StatusAccepted)
+		ctx.Writer.WriteHeader(http.StatusInternalServerError)
+	})
+
+	tests := []struct {
+		path    string
+		status  int
+		written bool
+	}{
+		{"/user/fprintf", http.StatusOK, true},
+		{"/user/file", http.StatusOK, true},
+		{"/user/missing", http.StatusNotFound, true},
+		{"/user/empty", http.StatusOK, false},
+		{"/user/twice", http.StatusAccepted, true},
+	}
+	for _, test := range tests {
+		writer := serve(engine, http.MethodGet, test.path)
+		if status != test.status || writer.Code != test.status || written != test.written || size != writer.Body.Len() {
+			t.Errorf("%s: got status %d written %v size %d, response %d %d",
+				test.path, status, written, size, writer.Code, writer.Body.Len())
+		}
+	}
+}
+
+// 实现了http.Hijacker但接管总是失败的ResponseWriter
+type failedHijacker struct {
+	*httptest.ResponseRecorder
+}
+
+func (failedHijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrHijacked
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	w := &responseWriter{}
+	w.reset(recorder)
+	w.Flush()
+	if !w.Written() || !recorder.Flushed {
+		t.Error("Flush should write the header and flush the underlying writer")
+	}
+	if _, _, err := w.Hijack(); err == nil {
+		t.Error("expected hijack error for a ResponseRecorder")
+	}
+	// 接管失败时响应仍未写出
+	w.reset(failedHijacker{httptest.NewRecorder()})
+	if _, _, err := w.Hijack(); err == nil || w.Written() {
+		t.Errorf("failed hijack should not mark the response written, err %v", err)
+	}
+	if err := w.Push("/main.css", nil); err != http.ErrNotSupported {
+		t.Errorf("expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestLoggingStatus(t *testing.T) {
+	var out bytes.Buffer
+	engine := MakeEngine()
+	engine.UseMiddleWare(func(next HandleFunc) HandleFunc {
+		return LoggingWithConfig(LoggingConfig{out: &out}, next)
+	})
+	engine.CreateGroup("user").Get("/fprintf", func(ctx *Context) {
+		_, _ = fmt.Fprint(ctx.Writer, "hello")
+	})
+	serve(engine, http.MethodGet, "/user/fprintf")
+	if !strings.Contains(out.String(), " 200 ") {
+		t.Errorf("access log should report status 200: %q", out.String())
+	}
+}
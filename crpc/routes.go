@@ -0,0 +1,74 @@
+package crpc
+
+import (
+	"fmt"
+	"github/CeerDecy/RpcFrameWork/crpc/crpcLogger"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+)
+
+// RouteInfo 路由信息
+type RouteInfo struct {
+	Method          string `json:"method"`
+	Path            string `json:"path"`
+	Name            string `json:"name,omitempty"`
+	Handler         string `json:"handler"`
+	MiddleWareCount int    `json:"middleWareCount"`
+}
+
+// RoutesInfo 路由表
+type RoutesInfo []RouteInfo
+
+// 获取函数名称
+func nameOfFunction(f any) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// 统计路由生效的中间件数量，包括全局、各级路由组和路由级别的中间件
+func (r *route) middleWareCount() int {
+	count := len(r.group.engine.middles) + len(r.middleWares)
+	for group := r.group; group != nil; group = group.parent {
+		count += len(group.middleWares)
+	}
+	return count
+}
+
+// Routes 获取路由表，按路径和请求方法排序，便于比对不同服务实例的路由
+func (e *Engine) Routes() RoutesInfo {
+	routes := make(RoutesInfo, 0, len(e.routes))
+	for _, r := range e.routes {
+		routes = append(routes, RouteInfo{
+			Method:          r.method,
+			Path:            r.path,
+			Name:            r.name,
+			Handler:         nameOfFunction(r.handleFunc),
+			MiddleWareCount: r.middleWareCount(),
+		})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// RoutesHandle 以JSON形式返回路由表，可按需注册到任意路由上
+// engine.CreateGroup("debug").Get("/routes", engine.RoutesHandle)
+func (e *Engine) RoutesHandle(ctx *Context) {
+	ctx.JSON(http.StatusOK, e.Routes())
+}
+
+// Debug级别的日志下，启动时打印路由表
+func (e *Engine) debugPrintRoutes() {
+	if e.Logger == nil || e.Logger.Level > crpcLogger.LevelDebug {
+		return
+	}
+	for _, r := range e.Routes() {
+		e.Logger.Debug("Route", fmt.Sprintf("%-7s %-35s --> %s (%d middlewares)",
+			r.Method, r.Path, r.Handler, r.MiddleWareCount))
+	}
+}
@@ -0,0 +1,45 @@
+package crpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func routesTestHandle(ctx *Context) {}
+
+func TestRoutes(t *testing.T) {
+	engine := MakeEngine()
+	engine.UseMiddleWare(Recovery)
+	group := engine.CreateGroup("user")
+	group.UseMiddleWare(Logging)
+	group.Post("/info", routesTestHandle, Recovery)
+	group.Get("/info", routesTestHandle).Name("user.info")
+	engine.CreateGroup("debug").Get("/routes", engine.RoutesHandle)
+
+	routes := engine.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("expected 3 routes, got %d", len(routes))
+	}
+	expected := []RouteInfo{
+		{Method: http.MethodGet, Path: "/debug/routes", MiddleWareCount: 1},
+		{Method: http.MethodGet, Path: "/user/info", Name: "user.info", MiddleWareCount: 2},
+		{Method: http.MethodPost, Path: "/user/info", MiddleWareCount: 3},
+	}
+	for i, route := range routes {
+		if route.Method != expected[i].Method || route.Path != expected[i].Path ||
+			route.Name != expected[i].Name || route.MiddleWareCount != expected[i].MiddleWareCount {
+			t.Errorf("route %d: expected %+v, got %+v", i, expected[i], route)
+		}
+	}
+	if !strings.HasSuffix(routes[1].Handler, "crpc.routesTestHandle") {
+		t.Errorf("unexpected handler name %s", routes[1].Handler)
+	}
+
+	writer := serve(engine, http.MethodGet, "/debug/routes")
+	var body RoutesInfo
+	if err := json.Unmarshal(writer.Body.Bytes(), &body); err != nil || len(body) != 3 {
+		t.Errorf("unexpected routes response %q %v", writer.Body.String(), err)
+	}
+}
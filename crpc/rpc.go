@@ -1,6 +1,7 @@
 package crpc
 
 import (
+	"context"
 	"fmt"
 	"github.com/nacos-group/nacos-sdk-go/v2/clients/naming_client"
 	"github/CeerDecy/RpcFrameWork/crpc/config"
@@ -8,12 +9,14 @@ import (
 	"github/CeerDecy/RpcFrameWork/crpc/gateway"
 	"github/CeerDecy/RpcFrameWork/crpc/register"
 	"github/CeerDecy/RpcFrameWork/crpc/render"
-	"github/CeerDecy/RpcFrameWork/crpc/utils"
 	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
 )
 
@@ -27,98 +30,143 @@ type MiddleWareFunc func(next HandleFunc) HandleFunc
 
 // 路由组
 type routerGroup struct {
-	groupName          string                                 // 组名
-	HandleFuncMap      map[string]map[string]HandleFunc       // 组中对应的路由方法
-	middleWaresFuncMap map[string]map[string][]MiddleWareFunc // 组中对应的路由方法
-	treeNode           treeNode                               // 路径前缀树
-	middleWares        []MiddleWareFunc                       // 中间件
-	//postMiddleWares []MiddleWareFunc                 // 后置中间件
+	groupName       string                           // 组名
+	basePath        string                           // 路由前缀，包含父组的前缀
+	HandleFuncMap   map[string]map[string]HandleFunc // 组中对应的路由方法
+	middleWares     []MiddleWareFunc                 // 中间件
+	engine          *Engine
+	parent          *routerGroup // 父路由组
+	postMiddleWares []HandleFunc // 后置中间件
 }
 
-// UseMiddleWare 添加前置中间件
+// UseMiddleWare 添加前置中间件，已注册路由的处理链会重新组合
 func (group *routerGroup) UseMiddleWare(wareFunc ...MiddleWareFunc) {
 	group.middleWares = append(group.middleWares, wareFunc...)
+	group.engine.rebuildHandlers()
 }
 
-//// PostMiddleWare 添加后置中间件
-//func (group *routerGroup) PostMiddleWare(wareFunc ...MiddleWareFunc) {
-//	group.postMiddleWares = append(group.postMiddleWares, wareFunc...)
-//}
+// PostMiddleWare 添加后置中间件，在处理链执行完毕后执行，即使请求被Abort也会执行
+func (group *routerGroup) PostMiddleWare(handleFunc ...HandleFunc) {
+	group.postMiddleWares = append(group.postMiddleWares, handleFunc...)
+}
 
-func (group *routerGroup) methodHandle(path string, method string, handleFunc HandleFunc, ctx *Context) {
-	// 前置中间件 -> 组级别通用中间件
-	if group.middleWares != nil {
-		for _, middleWareFunc := range group.middleWares {
-			handleFunc = middleWareFunc(handleFunc)
-		}
+// Group 创建子路由组，子组的路由前缀为父组前缀加上子组名，并继承父组的中间件
+func (group *routerGroup) Group(groupName string) *routerGroup {
+	child := &routerGroup{
+		groupName:     groupName,
+		basePath:      joinPaths(group.basePath, groupName),
+		HandleFuncMap: make(map[string]map[string]HandleFunc),
+		engine:        group.engine,
+		parent:        group,
+	}
+	group.engine.RouterGroups = append(group.engine.RouterGroups, child)
+	return child
+}
+
+// 组合处理链，执行顺序为：全局中间件 -> 父组中间件 -> 本组中间件 -> 路由中间件 -> 处理函数 -> 后置中间件
+func (group *routerGroup) combineHandlers(handleFunc HandleFunc, routeMiddleWares []MiddleWareFunc) HandleFunc {
+	handleFunc = chain(handleFunc, routeMiddleWares)
+	for g := group; g != nil; g = g.parent {
+		handleFunc = chain(handleFunc, g.middleWares)
+	}
+	handleFunc = chain(handleFunc, group.engine.middles)
+	return func(ctx *Context) {
+		handleFunc(ctx)
+		group.postHandle(ctx)
 	}
-	// 路由级别中间件
-	if group.middleWaresFuncMap[path][method] != nil {
-		for _, middleWareFunc := range group.middleWaresFuncMap[path][method] {
-			handleFunc = middleWareFunc(handleFunc)
+}
+
+// 执行后置中间件，顺序为：本组 -> 父组 -> 全局
+func (group *routerGroup) postHandle(ctx *Context) {
+	for g := group; g != nil; g = g.parent {
+		for _, handleFunc := range g.postMiddleWares {
+			handleFunc(ctx)
 		}
 	}
-	handleFunc(ctx)
-	// 后置中间件
-	//if group.postMiddleWares != nil {
-	//	for _, middleWareFunc := range group.postMiddleWares {
-	//		handleFunc = middleWareFunc(handleFunc)
-	//	}
-	//}
-	//handleFunc(ctx)
+	for _, handleFunc := range group.engine.postMiddles {
+		handleFunc(ctx)
+	}
 }
 
-func (group *routerGroup) handle(route, method string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	if _, ok := group.HandleFuncMap[route]; !ok {
-		group.HandleFuncMap[route] = make(map[string]HandleFunc)
-		group.middleWaresFuncMap[route] = make(map[string][]MiddleWareFunc)
+// 注册路由，返回的路由信息可以通过Name方法设置路由名称
+func (group *routerGroup) handle(path, method string, handleFunc HandleFunc, middleware ...MiddleWareFunc) *route {
+	if _, ok := group.HandleFuncMap[path]; !ok {
+		group.HandleFuncMap[path] = make(map[string]HandleFunc)
 	}
-	if _, ok := group.HandleFuncMap[route][method]; ok {
+	if _, ok := group.HandleFuncMap[path][method]; ok {
 		panic("this crpc has exist")
 	}
-	group.HandleFuncMap[route][method] = handleFunc
-	group.middleWaresFuncMap[route][method] = append(group.middleWaresFuncMap[route][method], middleware...)
-	group.treeNode.Put(route)
+	group.HandleFuncMap[path][method] = handleFunc
+	r := &route{
+		method:      method,
+		path:        joinPaths(group.basePath, path),
+		relative:    path,
+		group:       group,
+		handleFunc:  handleFunc,
+		middleWares: middleware,
+	}
+	r.handler = group.combineHandlers(handleFunc, middleware)
+	r.optionsHandler = group.combineHandlers(optionsHandle, nil)
+	group.engine.addRoute(method, r.path, r)
+	return r
+}
+
+// Route 注册路由后返回，可以通过Name方法设置路由名称
+type Route interface {
+	Name(name string) Route
+}
+
+// 路由信息，挂载在路由树的节点上
+type route struct {
+	method         string
+	path           string // 完整路由，包含组前缀
+	relative       string // 组内路由
+	name           string // 路由名称，用于反向生成URL
+	group          *routerGroup
+	handleFunc     HandleFunc
+	middleWares    []MiddleWareFunc // 路由级别中间件
+	handler        HandleFunc       // 注册时组合好的完整处理链
+	optionsHandler HandleFunc       // 自动响应OPTIONS请求的处理链，经过全局和路由组的中间件
 }
 
 // Any 为当前组别添加路由方法
-func (group *routerGroup) Any(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, MethodAny, handleFunc, middleware...)
+func (group *routerGroup) Any(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, MethodAny, handleFunc, middleware...)
 }
 
 // Get 配置Get请求的路由
-func (group *routerGroup) Get(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodGet, handleFunc, middleware...)
+func (group *routerGroup) Get(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodGet, handleFunc, middleware...)
 }
 
 // Post 配置Post请求的路由
-func (group *routerGroup) Post(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodPost, handleFunc, middleware...)
+func (group *routerGroup) Post(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodPost, handleFunc, middleware...)
 }
 
 // Delete 配置Delete请求的路由
-func (group *routerGroup) Delete(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodDelete, handleFunc, middleware...)
+func (group *routerGroup) Delete(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodDelete, handleFunc, middleware...)
 }
 
 // Put 配置Put请求的路由
-func (group *routerGroup) Put(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodPut, handleFunc, middleware...)
+func (group *routerGroup) Put(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodPut, handleFunc, middleware...)
 }
 
 // Patch 配置Patch请求的路由
-func (group *routerGroup) Patch(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodPatch, handleFunc, middleware...)
+func (group *routerGroup) Patch(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodPatch, handleFunc, middleware...)
 }
 
 // Options 配置Patch请求的路由
-func (group *routerGroup) Options(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodOptions, handleFunc, middleware...)
+func (group *routerGroup) Options(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodOptions, handleFunc, middleware...)
 }
 
 // Head 配置Patch请求的路由
-func (group *routerGroup) Head(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) {
-	group.handle(route, http.MethodHead, handleFunc, middleware...)
+func (group *routerGroup) Head(route string, handleFunc HandleFunc, middleware ...MiddleWareFunc) Route {
+	return group.handle(route, http.MethodHead, handleFunc, middleware...)
 }
 
 // 用于存储路由表
@@ -127,16 +175,15 @@ type router struct {
 	engine       *Engine
 }
 
-// CreateGroup 添加组别
+// CreateGroup 添加组别，组名作为路由前缀，可以包含多级路径，如 api/v1
 func (r *router) CreateGroup(groupName string) *routerGroup {
 	group := &routerGroup{
-		groupName:          groupName,
-		HandleFuncMap:      make(map[string]map[string]HandleFunc),
-		middleWaresFuncMap: make(map[string]map[string][]MiddleWareFunc),
+		groupName:     groupName,
+		basePath:      joinPaths("", groupName),
+		HandleFuncMap: make(map[string]map[string]HandleFunc),
 		//HandleMethodMap: make(map[string][]string),
-		treeNode: treeNode{name: groupName},
+		engine: r.engine,
 	}
-	group.middleWares = r.engine.middles
 	r.RouterGroups = append(r.RouterGroups, group)
 	return group
 }
@@ -151,12 +198,32 @@ type Engine struct {
 	Pool             sync.Pool
 	Logger           *crpcLogger.Logger
 	middles          []MiddleWareFunc
+	postMiddles      []HandleFunc
 	errorHandler     ErrorHandler
 	OpenGateway      bool
 	gatewayConfigs   []*gateway.GWConfig
 	gatewayTreeNode  *gateway.TreeNode
 	gatewayConfigMap map[string]*gateway.GWConfig
 	RegClient        naming_client.INamingClient
+	trees            methodTrees                 // 按HTTP方法划分的路由树，组名作为路由前缀
+	noRoute          HandleFunc                  // 路由不存在时的处理函数
+	noMethod         HandleFunc                  // 请求方法不被允许时的处理函数
+	noRouteHandler   HandleFunc                  // 组合了全局中间件的404处理链
+	noMethodHandler  HandleFunc                  // 组合了全局中间件的405处理链
+	gatewayHandler   HandleFunc                  // 组合了全局中间件的网关处理链
+	namedRoutes      map[string]*route           // 命名路由，用于反向生成URL
+	maxParams        int                         // 单个路由中路径参数的最大数量
+	routes           []*route                    // 按注册顺序保存的全部路由
+	server           *http.Server                // 正在运行的http服务
+	serverDone       chan struct{}               // Shutdown执行完毕时关闭，正在退出时不为空
+	serverLock       sync.Mutex                  // 保护server、serverDone、started和shuttingDown
+	started          bool                        // 是否启动过
+	shuttingDown     bool                        // 启动前收到了Shutdown
+	instance         *serviceInstance            // 注册到注册中心的服务实例
+	onStart          []func()                    // 启动钩子
+	onShutdown       []func(ctx context.Context) // 退出钩子
+	RemoteIPHeaders  []string                    // 对端为可信代理时用于获取客户端IP的请求头，默认为Forwarded、X-Forwarded-For、X-Real-IP
+	trustedCIDRs     []*net.IPNet                // 可信代理，通过SetTrustedProxies设置
 }
 
 // MakeEngine 初始化引擎
@@ -168,7 +235,12 @@ func MakeEngine() *Engine {
 			Child: make([]*gateway.TreeNode, 0),
 		},
 		gatewayConfigMap: make(map[string]*gateway.GWConfig),
+		namedRoutes:      make(map[string]*route),
+		RemoteIPHeaders:  append([]string(nil), defaultRemoteIPHeaders...),
 	}
+	e.router.engine = e
+	e.funcMap = template.FuncMap{"urlFor": e.URLFor}
+	e.rebuildHandlers()
 	e.Pool.New = func() any {
 		return e.allocateContext()
 	}
@@ -186,7 +258,6 @@ func DefaultEngine() *Engine {
 			log.Fatalln("log size config is null")
 		}
 	}
-	engine.router.engine = engine
 	return engine
 }
 
@@ -203,12 +274,200 @@ func (e *Engine) SetGatewayConfig(conf []*gateway.GWConfig) {
 func (e *Engine) allocateContext() any {
 	return &Context{
 		engine: e,
+		params: make(Params, 0, e.maxParams),
+	}
+}
+
+// 网关处理逻辑，将请求转发到注册中心中对应的服务
+func (e *Engine) gatewayHandle(ctx *Context) {
+	request := ctx.Request
+	path := request.URL.Path
+	node := e.gatewayTreeNode.Get(path)
+	if node == nil {
+		ctx.Writer.WriteHeader(http.StatusNotFound)
+		ctx.Logger.Error("Gateway", "404 not found")
+		return
+	}
+	fmt.Println(node.Name)
+	gwConfig := e.gatewayConfigMap[node.GwName]
+	instance, port, err := register.GetInstance(e.RegClient, gwConfig.ServiceName)
+	if err != nil {
+		ctx.Writer.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Gateway", err.Error())
+		return
+	}
+	fmt.Println(fmt.Sprintf("http://%s:%d%s", instance, port, path))
+	target, err := url.Parse(fmt.Sprintf("http://%s:%d%s", instance, port, path))
+	if err != nil {
+		ctx.Writer.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Gateway", err.Error())
+		return
+	}
+	// 重定向请求
+	director := func(request *http.Request) {
+		request.Host = target.Host
+		request.URL.Host = target.Host
+		request.URL.Path = target.Path
+		request.URL.Scheme = target.Scheme
+		if _, ok := request.Header["User-Agent"]; !ok {
+			request.Header.Set("User-Agent", "")
+		}
+		if id := ctx.RequestID(); id != "" {
+			request.Header.Set(RequestIDHeader, id)
+		}
+		setForwardedHeaders(ctx, request)
+		if gwConfig.Header != nil {
+			gwConfig.Header(request)
+		}
+		fmt.Println("请求")
+	}
+	response := func(response *http.Response) error {
+		fmt.Println("响应修改")
+		return nil
+	}
+	handler := func(writer http.ResponseWriter, request *http.Request, err error) {
+		e.Logger.Error("Gateway Error", err.Error())
+		fmt.Println("错误处理")
+	}
+	proxy := httputil.ReverseProxy{Director: director, ModifyResponse: response, ErrorHandler: handler}
+	proxy.ServeHTTP(ctx.Writer, request)
+}
+
+// 将路由添加到对应方法的路由树中
+func (e *Engine) addRoute(method, path string, r *route) {
+	root := e.trees.get(method)
+	if root == nil {
+		root = &node{}
+		e.trees = append(e.trees, methodTree{method: method, root: root})
+	}
+	root.addRoute(path, r)
+	e.routes = append(e.routes, r)
+	if n := countParams(path); n > e.maxParams {
+		e.maxParams = n
+	}
+}
+
+// 查找请求对应的路由，优先匹配具体方法的路由，其次是Any路由
+func (e *Engine) getRoute(method, path string, params *Params) *route {
+	if root := e.trees.get(method); root != nil {
+		if n := root.getValue(path, params); n != nil {
+			return n.route
+		}
+	}
+	if root := e.trees.get(MethodAny); root != nil {
+		if n := root.getValue(path, params); n != nil {
+			return n.route
+		}
+	}
+	return nil
+}
+
+// 获取路由已注册的请求方法，GET路由自动支持HEAD，所有路由自动支持OPTIONS；
+// 同时返回第一个匹配的路由，用于确定自动响应OPTIONS时经过的路由组中间件
+func (e *Engine) allowedMethods(path string, params *Params) ([]string, *route) {
+	var methods []string
+	var matched *route
+	for _, tree := range e.trees {
+		if tree.method == MethodAny {
+			continue
+		}
+		n := tree.root.getValue(path, params)
+		if n == nil {
+			continue
+		}
+		*params = (*params)[:0]
+		methods = append(methods, tree.method)
+		if matched == nil {
+			matched = n.route
+		}
+	}
+	if len(methods) == 0 {
+		return nil, nil
+	}
+	hasHead, hasOptions, hasGet := false, false, false
+	for _, method := range methods {
+		switch method {
+		case http.MethodHead:
+			hasHead = true
+		case http.MethodOptions:
+			hasOptions = true
+		case http.MethodGet:
+			hasGet = true
+		}
+	}
+	if hasGet && !hasHead {
+		methods = append(methods, http.MethodHead)
+	}
+	if !hasOptions {
+		methods = append(methods, http.MethodOptions)
 	}
+	sort.Strings(methods)
+	return methods, matched
+}
+
+// NoRoute 设置路由不存在时的处理函数，处理函数会经过全局中间件
+func (e *Engine) NoRoute(handleFunc HandleFunc) {
+	e.noRoute = handleFunc
+	e.rebuildHandlers()
+}
+
+// NoMethod 设置请求方法不被允许时的处理函数，处理函数会经过全局中间件
+// 调用处理函数前Allow请求头已经设置好
+func (e *Engine) NoMethod(handleFunc HandleFunc) {
+	e.noMethod = handleFunc
+	e.rebuildHandlers()
+}
+
+// 使用全局中间件包装处理函数，处理链结束后执行全局后置中间件
+func (e *Engine) combineHandlers(handleFunc HandleFunc) HandleFunc {
+	handleFunc = chain(handleFunc, e.middles)
+	return func(ctx *Context) {
+		handleFunc(ctx)
+		for _, postFunc := range e.postMiddles {
+			postFunc(ctx)
+		}
+	}
+}
+
+// 重新组合所有处理链，中间件或处理函数变化时调用，请求处理时不再组合中间件
+func (e *Engine) rebuildHandlers() {
+	for _, r := range e.routes {
+		r.handler = r.group.combineHandlers(r.handleFunc, r.middleWares)
+		r.optionsHandler = r.group.combineHandlers(optionsHandle, nil)
+	}
+	noRoute, noMethod := e.noRoute, e.noMethod
+	if noRoute == nil {
+		noRoute = notFoundHandle
+	}
+	if noMethod == nil {
+		noMethod = methodNotAllowedHandle
+	}
+	e.noRouteHandler = e.combineHandlers(noRoute)
+	e.noMethodHandler = e.combineHandlers(noMethod)
+	e.gatewayHandler = e.combineHandlers(e.gatewayHandle)
+}
+
+// 默认的404处理函数
+func notFoundHandle(ctx *Context) {
+	ctx.String(http.StatusNotFound, "404 %s resource not found", ctx.Request.RequestURI)
+}
+
+// 默认的405处理函数
+func methodNotAllowedHandle(ctx *Context) {
+	ctx.String(http.StatusMethodNotAllowed, "%s is not allowed", ctx.Request.RequestURI)
 }
 
-// SetFuncMap 设置FuncMap
+// 自动响应OPTIONS请求，Allow请求头由调用方设置
+func optionsHandle(ctx *Context) {
+	ctx.Writer.WriteHeader(http.StatusNoContent)
+}
+
+// SetFuncMap 设置FuncMap，内置的urlFor函数会被保留，除非被同名函数覆盖
 func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
-	e.funcMap = funcMap
+	e.funcMap = template.FuncMap{"urlFor": e.URLFor}
+	for name, fn := range funcMap {
+		e.funcMap[name] = fn
+	}
 }
 
 // LoadTemplate 根据路径通配符加载模板
@@ -225,22 +484,14 @@ func (e *Engine) SetTemplate(t *template.Template) {
 // 实现Handler接口
 func (e *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 	ctx := e.Pool.Get().(*Context)
-	ctx.Writer = writer
-	ctx.Request = request
-	ctx.Logger = e.Logger
-	e.HttpRequestHandle(ctx, writer, request)
+	ctx.reset(writer, request)
+	e.HttpRequestHandle(ctx, ctx.Writer, request)
 	e.Pool.Put(ctx)
 }
 
-// Run 启动引擎
+// Run 启动引擎，收到SIGINT或SIGTERM时优雅退出
 func (e *Engine) Run(address string) {
-	client, err := register.CreateNacosClient()
-	if err != nil {
-		e.Logger.Debug("run register", err)
-	}
-	e.RegClient = client
-	http.Handle("/", e)
-	err = http.ListenAndServe(address, nil)
+	err := e.RunWithOptions(RunOptions{Addr: address})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -248,86 +499,51 @@ func (e *Engine) Run(address string) {
 
 func (e *Engine) HttpRequestHandle(ctx *Context, writer http.ResponseWriter, request *http.Request) {
 	if e.OpenGateway {
-		// 网关处理逻辑
-		path := request.URL.Path
-		node := e.gatewayTreeNode.Get(path)
-		if node == nil {
-			writer.WriteHeader(http.StatusNotFound)
-			ctx.Logger.Error("Gateway", "404 not found")
-			return
-		}
-		fmt.Println(node.Name)
-		gwConfig := e.gatewayConfigMap[node.GwName]
-		instance, port, err := register.GetInstance(e.RegClient, gwConfig.ServiceName)
-		if err != nil {
-			writer.WriteHeader(http.StatusInternalServerError)
-			ctx.Logger.Error("Gateway", err.Error())
-			return
-		}
-		fmt.Println(fmt.Sprintf("http://%s:%d%s", instance, port, path))
-		target, err := url.Parse(fmt.Sprintf("http://%s:%d%s", instance, port, path))
-		if err != nil {
-			writer.WriteHeader(http.StatusInternalServerError)
-			ctx.Logger.Error("Gateway", err.Error())
-			return
-		}
-		// 重定向请求
-		director := func(request *http.Request) {
-			request.Host = target.Host
-			request.URL.Host = target.Host
-			request.URL.Path = target.Path
-			request.URL.Scheme = target.Scheme
-			if _, ok := request.Header["User-Agent"]; !ok {
-				request.Header.Set("User-Agent", "")
-			}
-			if gwConfig.Header != nil {
-				gwConfig.Header(request)
-			}
-			fmt.Println("请求")
-		}
-		response := func(response *http.Response) error {
-			fmt.Println("响应修改")
-			return nil
-		}
-		handler := func(writer http.ResponseWriter, request *http.Request, err error) {
-			e.Logger.Error("Gateway Error", err.Error())
-			fmt.Println("错误处理")
-		}
-		proxy := httputil.ReverseProxy{Director: director, ModifyResponse: response, ErrorHandler: handler}
-		proxy.ServeHTTP(writer, request)
+		e.gatewayHandler(ctx)
 		return
 	}
 	// 获取当前请求的方法
 	method := request.Method
-	// 遍历Group
-	for _, group := range e.RouterGroups {
-		routeName := utils.SubStringLast(request.URL.Path, "/"+group.groupName)
-		node := group.treeNode.Get(routeName)
-		if node != nil && node.isEnd {
-			// 若请求方式为Any，则直接运行Any中的方法
-			if handleFunc, ok := group.HandleFuncMap[node.routePath][MethodAny]; ok {
-				group.methodHandle(node.routePath, MethodAny, handleFunc, ctx)
-				return
-			}
-			// 若请求方式为method，那么执行method中的方法
-			if handleFunc, ok := group.HandleFuncMap[node.routePath][method]; ok {
-				group.methodHandle(node.routePath, method, handleFunc, ctx)
-				return
-			}
-			// 执行到这说明当前路由请求的方法不被服务器所支持
-			writer.WriteHeader(http.StatusMethodNotAllowed)
-			_, _ = fmt.Fprintf(writer, "%s is not allowed", request.RequestURI)
+	path := request.URL.Path
+	if r := e.getRoute(method, path, &ctx.params); r != nil {
+		ctx.fullPath = r.path
+		r.handler(ctx)
+		return
+	}
+	// HEAD请求自动使用GET路由处理，并丢弃响应体
+	if method == http.MethodHead {
+		if r := e.getRoute(http.MethodGet, path, &ctx.params); r != nil {
+			ctx.fullPath = r.path
+			w := ctx.Writer
+			ctx.Writer = &headResponseWriter{ResponseWriter: w}
+			r.handler(ctx)
+			ctx.Writer = w
 			return
 		}
 	}
-	// 遍历完成
-	writer.WriteHeader(http.StatusNotFound)
-	_, _ = writer.Write([]byte("404 " + request.RequestURI + " resource not found"))
+	if allowed, r := e.allowedMethods(path, &ctx.params); allowed != nil {
+		writer.Header().Set("Allow", strings.Join(allowed, ", "))
+		if method == http.MethodOptions {
+			ctx.fullPath = r.path
+			r.optionsHandler(ctx)
+			return
+		}
+		// 执行到这说明当前路由请求的方法不被服务器所支持
+		e.noMethodHandler(ctx)
+		return
+	}
+	e.noRouteHandler(ctx)
 }
 
-// UseMiddleWare 配置中间件
+// UseMiddleWare 添加全局中间件，对所有路由组以及NoRoute、NoMethod处理函数生效
 func (e *Engine) UseMiddleWare(middles ...MiddleWareFunc) {
-	e.middles = middles
+	e.middles = append(e.middles, middles...)
+	e.rebuildHandlers()
+}
+
+// PostMiddleWare 添加全局后置中间件，在处理链执行完毕后执行，即使请求被Abort也会执行
+func (e *Engine) PostMiddleWare(handleFunc ...HandleFunc) {
+	e.postMiddles = append(e.postMiddles, handleFunc...)
 }
 
 func (e *Engine) RegisterErrorHandler(handler ErrorHandler) {
@@ -336,7 +552,7 @@ func (e *Engine) RegisterErrorHandler(handler ErrorHandler) {
 
 // RunTLS 开启HTTPS
 func (e *Engine) RunTLS(addr, certFile, keyFile string) {
-	err := http.ListenAndServeTLS(addr, certFile, keyFile, e.Handler())
+	err := e.RunWithOptions(RunOptions{Addr: addr, CertFile: certFile, KeyFile: keyFile})
 	if err != nil {
 		log.Fatalln(err)
 	}
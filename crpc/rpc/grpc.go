@@ -75,11 +75,17 @@ func WithGrpcOptions(ops ...grpc.ServerOption) GrpcOption {
 
 // GrpcClient GRPC客户端
 type GrpcClient struct {
-	Conn *grpc.ClientConn
+	Conn        *grpc.ClientConn
+	readTimeout time.Duration
 }
 
+// NewGrpcClient 创建GRPC客户端
 func NewGrpcClient(config *GrpcClientOption) (*GrpcClient, error) {
-	ctx := context.Background()
+	return NewGrpcClientContext(context.Background(), config)
+}
+
+// NewGrpcClientContext 创建GRPC客户端，阻塞连接时ctx取消或超时会停止连接
+func NewGrpcClientContext(ctx context.Context, config *GrpcClientOption) (*GrpcClient, error) {
 	dialOption := config.dialOptions
 	if config.Block {
 		if config.DialTimeout > time.Duration(0) {
@@ -97,10 +103,19 @@ func NewGrpcClient(config *GrpcClientOption) (*GrpcClient, error) {
 		return nil, err
 	}
 	return &GrpcClient{
-		Conn: conn,
+		Conn:        conn,
+		readTimeout: config.ReadTimeout,
 	}, err
 }
 
+// Context 基于ctx创建一个带ReadTimeout超时的上下文，用于单次调用；ReadTimeout未设置时只继承ctx
+func (c *GrpcClient) Context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.readTimeout > 0 {
+		return context.WithTimeout(ctx, c.readTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
 type GrpcClientOption struct {
 	Address     string
 	Block       bool
@@ -9,14 +9,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github/CeerDecy/RpcFrameWork/crpc/limiter"
 	"github/CeerDecy/RpcFrameWork/crpc/register"
 	"golang.org/x/time/rate"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/structpb"
 	"io"
 	"log"
+	"math"
 	"net"
 	"reflect"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -35,6 +38,34 @@ import (
 const MagicNumber byte = 0x1d
 const Version byte = 0x01
 
+// VersionTrace 携带链路追踪ID的协议版本，消息头之后紧跟2字节的长度与链路追踪ID
+const VersionTrace byte = 0x02
+
+// 上下文中链路追踪ID的键，使用私有类型避免与其他包的键冲突
+type traceIdKey struct{}
+
+// WithTraceId 返回携带链路追踪ID的上下文
+func WithTraceId(ctx context.Context, traceId string) context.Context {
+	return context.WithValue(ctx, traceIdKey{}, traceId)
+}
+
+// TraceIdFromContext 获取上下文中通过WithTraceId设置的链路追踪ID，
+// TcpRpcServer中服务方法的第一个参数为context.Context时可以通过它获取调用方的请求ID
+func TraceIdFromContext(ctx context.Context) string {
+	traceId, _ := ctx.Value(traceIdKey{}).(string)
+	return traceId
+}
+
+// 在消息头之后追加链路追踪ID，没有链路追踪ID时保持原有的协议格式
+func appendTraceId(header []byte, traceId string) []byte {
+	if traceId == "" || len(traceId) > math.MaxUint16 {
+		return header
+	}
+	header[1] = VersionTrace
+	header = binary.BigEndian.AppendUint16(header, uint16(len(traceId)))
+	return append(header, traceId...)
+}
+
 // Serializer 序列化接口
 type Serializer interface {
 	Serialize(data any) ([]byte, error)
@@ -146,6 +177,7 @@ type Header struct {
 	CompressType  CompressType
 	SerializeType SerializerType
 	RequestId     int64
+	TraceId       string // 链路追踪ID，协议版本为VersionTrace时携带
 }
 
 // CrRpcMessage 消息
@@ -191,8 +223,8 @@ type TcpRpcServer struct {
 	port           uint64
 	listen         net.Listener
 	serviceMap     map[string]any
-	Limiter        *rate.Limiter
-	LimiterTimeout time.Duration
+	Limiter        limiter.Limiter // 限流器，为nil时不限流
+	LimiterTimeout time.Duration   // 等待许可的最长时间
 }
 
 // NewTcpRpcServer TcpRpcServer构造器
@@ -209,8 +241,16 @@ func NewTcpRpcServer(addr string, port uint64) *TcpRpcServer {
 	}
 }
 
+// SetLimiter 使用令牌桶限流，limit为每秒生成的令牌数，cap为令牌桶容量
 func (t *TcpRpcServer) SetLimiter(limit, cap int) {
-	t.Limiter = rate.NewLimiter(rate.Limit(limit), cap)
+	t.Limiter = limiter.NewTokenBucket(rate.Limit(limit), cap)
+}
+
+// SetAdaptiveLimiter 使用自适应并发限流，返回的限流器可用于获取当前的并发上限
+func (t *TcpRpcServer) SetAdaptiveLimiter(conf limiter.AdaptiveConfig) *limiter.Adaptive {
+	adaptive := limiter.NewAdaptive(conf)
+	t.Limiter = adaptive
+	return adaptive
 }
 
 // Register 注册服务
@@ -320,27 +360,26 @@ func (t *TcpRpcServer) readHandle(conn *TcpConn) error {
 			_ = conn.conn.Close()
 		}
 	}()
-	// 添加限流
-	timeout, cancelFunc := context.WithTimeout(context.Background(), t.LimiterTimeout)
-	defer cancelFunc()
-	err := t.Limiter.Wait(timeout)
+	// 接收数据
+	msg, err := decodeFrame(conn.conn)
 	if err != nil {
 		rsp := &CrRpcResponse{}
 		rsp.Code = 500
 		rsp.Msg = err.Error()
 		conn.rpcChan <- rsp
+		log.Println("server readHandle", err)
 		return err
 	}
-	// 接收数据
-	msg, err := decodeFrame(conn.conn)
+	// 添加限流，读取到完整请求之后再获取许可，等待客户端发送数据的时间不占用名额，也不计入延迟
+	release, err := t.acquire()
 	if err != nil {
 		rsp := &CrRpcResponse{}
 		rsp.Code = 500
 		rsp.Msg = err.Error()
 		conn.rpcChan <- rsp
-		log.Println("server readHandle", err)
 		return err
 	}
+	defer release()
 	if msg.Header.MessageType == msgRequest {
 		if msg.Header.SerializeType == PROTOBUF {
 			request := msg.Data.(*Request)
@@ -365,10 +404,10 @@ func (t *TcpRpcServer) readHandle(conn *TcpConn) error {
 				conn.rpcChan <- rsp
 				return err
 			}
-			param := make([]reflect.Value, len(request.Args))
+			param, offset := methodParams(method, msg.Header, len(request.Args))
 			for i := range request.Args {
 				of := reflect.ValueOf(request.Args[i].AsInterface())
-				param[i] = of.Convert(method.Type().In(i))
+				param[i+offset] = of.Convert(method.Type().In(i + offset))
 			}
 			res := method.Call(param)
 			results := make([]any, len(res))
@@ -408,9 +447,9 @@ func (t *TcpRpcServer) readHandle(conn *TcpConn) error {
 				conn.rpcChan <- rsp
 				return err
 			}
-			param := make([]reflect.Value, len(request.Args))
+			param, offset := methodParams(method, msg.Header, len(request.Args))
 			for i, v := range request.Args {
-				param[i] = reflect.ValueOf(v)
+				param[i+offset] = reflect.ValueOf(v)
 			}
 			res := method.Call(param)
 			results := make([]any, len(res))
@@ -432,6 +471,33 @@ func (t *TcpRpcServer) readHandle(conn *TcpConn) error {
 	return err
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// 创建服务方法的参数列表，方法的第一个参数为context.Context时传入携带链路追踪ID的上下文，
+// offset为请求参数在参数列表中的起始位置
+func methodParams(method reflect.Value, header *Header, argCount int) (param []reflect.Value, offset int) {
+	if method.Type().NumIn() > 0 && method.Type().In(0) == contextType {
+		ctx := context.Background()
+		if header.TraceId != "" {
+			ctx = WithTraceId(ctx, header.TraceId)
+		}
+		param = make([]reflect.Value, argCount+1)
+		param[0] = reflect.ValueOf(ctx)
+		return param, 1
+	}
+	return make([]reflect.Value, argCount), 0
+}
+
+// 获取限流许可，最多等待LimiterTimeout
+func (t *TcpRpcServer) acquire() (func(), error) {
+	if t.Limiter == nil {
+		return func() {}, nil
+	}
+	timeout, cancelFunc := context.WithTimeout(context.Background(), t.LimiterTimeout)
+	defer cancelFunc()
+	return t.Limiter.Acquire(timeout)
+}
+
 // 发送数据
 func (t *TcpRpcServer) writeHandle(conn *TcpConn) {
 	select {
@@ -464,7 +530,31 @@ func decodeFrame(conn net.Conn) (*CrRpcMessage, error) {
 			RequestId:     int64(binary.BigEndian.Uint64(headers[9:])),
 		},
 	}
+	if fullLength < 17 {
+		return nil, fmt.Errorf("invalid full length %d", fullLength)
+	}
 	bodyLen := fullLength - 17
+	switch msg.Header.Version {
+	case Version:
+	case VersionTrace:
+		traceLen := make([]byte, 2)
+		if _, err = io.ReadFull(conn, traceLen); err != nil {
+			return nil, err
+		}
+		// 链路追踪ID的长度不能超过消息体的长度
+		n := int32(binary.BigEndian.Uint16(traceLen))
+		if 2+n > bodyLen {
+			return nil, fmt.Errorf("invalid trace id length %d, full length %d", n, fullLength)
+		}
+		traceId := make([]byte, n)
+		if _, err = io.ReadFull(conn, traceId); err != nil {
+			return nil, err
+		}
+		msg.Header.TraceId = string(traceId)
+		bodyLen -= 2 + n
+	default:
+		return nil, fmt.Errorf("unsupported version %d", msg.Header.Version)
+	}
 	//body := make([]byte, 1024)
 	body := make([]byte, bodyLen)
 	_, err = io.ReadFull(conn, body)
@@ -577,6 +667,11 @@ func NewTcpClient(option TcpClientOption) *TcpClient {
 
 // Connect 获取链接
 func (t *TcpClient) Connect() error {
+	return t.ConnectContext(context.Background())
+}
+
+// ConnectContext 获取链接，ctx取消或超时时停止连接
+func (t *TcpClient) ConnectContext(ctx context.Context) error {
 	// 从注册中心获取ip和端口
 	client, err := register.CreateNacosClient()
 	if err != nil {
@@ -588,8 +683,9 @@ func (t *TcpClient) Connect() error {
 	}
 	t.option.Host = host
 	t.option.Port = int(port)
-	addr := fmt.Sprintf("%s:%d", t.option.Host, t.option.Port)
-	conn, err := net.DialTimeout("tcp", addr, t.option.ConnectionTimeout)
+	addr := net.JoinHostPort(t.option.Host, strconv.Itoa(t.option.Port))
+	dialer := net.Dialer{Timeout: t.option.ConnectionTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
 	if err != nil {
 		return err
 	}
@@ -599,8 +695,11 @@ func (t *TcpClient) Connect() error {
 
 var reqId int64
 
-// Invoke 调用RPC
+// Invoke 调用RPC，ctx的截止时间会作用于请求的发送，等待响应时ctx结束则关闭连接并返回ctx.Err()
 func (t *TcpClient) Invoke(ctx context.Context, serviceName, method string, args []any) (any, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	// 设置请求体
 	req := &CrRpcRequest{}
 	req.RequestId = atomic.AddInt64(&reqId, 1)
@@ -615,6 +714,7 @@ func (t *TcpClient) Invoke(ctx context.Context, serviceName, method string, args
 	header[7] = byte(t.option.CompressType)
 	header[8] = byte(t.option.SerializerType)
 	binary.BigEndian.PutUint64(header[9:], uint64(req.RequestId))
+	header = appendTraceId(header, TraceIdFromContext(ctx))
 	//
 	serializer := loadSerializer(t.option.SerializerType)
 	var body []byte
@@ -644,9 +744,11 @@ func (t *TcpClient) Invoke(ctx context.Context, serviceName, method string, args
 	if err != nil {
 		return nil, err
 	}
-	fullLength := 17 + len(body)
+	fullLength := len(header) + len(body)
 	binary.BigEndian.PutUint32(header[2:6], uint32(fullLength))
 
+	deadline, _ := ctx.Deadline()
+	_ = t.conn.SetWriteDeadline(deadline)
 	_, err = t.conn.Write(header[:])
 	if err != nil {
 		return nil, err
@@ -655,10 +757,16 @@ func (t *TcpClient) Invoke(ctx context.Context, serviceName, method string, args
 	if err != nil {
 		return nil, err
 	}
-	rspChan := make(chan *CrRpcResponse)
+	rspChan := make(chan *CrRpcResponse, 1)
 	go t.readHandle(rspChan)
-	rsp := <-rspChan
-	return rsp, nil
+	select {
+	case rsp := <-rspChan:
+		return rsp, nil
+	case <-ctx.Done():
+		// 关闭连接使读取协程退出
+		_ = t.conn.Close()
+		return nil, ctx.Err()
+	}
 }
 
 // 等待响应并通过通道返回数据
@@ -715,17 +823,23 @@ func NewTcpClientProxy(option TcpClientOption) *TcpClientProxy {
 	return &TcpClientProxy{option: option}
 }
 
+// Call 连接服务并调用RPC，失败时重试，ctx取消或超时后不再重试
 func (c *TcpClientProxy) Call(ctx context.Context, serviceName, method string, args []any) (any, error) {
 	client := NewTcpClient(c.option)
 	client.ServiceName = serviceName
 	c.client = client
-	err := client.Connect()
+	err := client.ConnectContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	for i := 0; i < c.option.Retries; i++ {
 		result, err := client.Invoke(ctx, serviceName, method, args)
 		if err != nil {
+			// 调用已被取消或超时，不再重试
+			if ctx.Err() != nil {
+				_ = client.Close()
+				return nil, err
+			}
 			if i >= c.option.Retries-1 {
 				log.Println("already retry all time")
 				_ = client.Close()
@@ -0,0 +1,197 @@
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github/CeerDecy/RpcFrameWork/crpc/limiter"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestInvokeContext(t *testing.T) {
+	// 只接收连接但从不响应的服务端
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			_, _ = conn.Read(make([]byte, 1024))
+			time.Sleep(time.Second)
+		}
+	}()
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewTcpClient(DefaultTcpClientOption)
+	client.conn = conn
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.Invoke(ctx, "goods", "Find", []any{int64(1001)})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Invoke should return as soon as the context is done")
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = client.Invoke(canceled, "goods", "Find", nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected canceled, got %v", err)
+	}
+}
+
+func TestServerLimiter(t *testing.T) {
+	server := &TcpRpcServer{LimiterTimeout: 10 * time.Millisecond}
+	release, err := server.acquire()
+	if err != nil {
+		t.Fatalf("server without limiter should not limit, got %v", err)
+	}
+	release()
+	server.Limiter = limiter.NewConcurrency(1)
+	release, err = server.acquire()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.acquire(); err != limiter.ErrLimited {
+		t.Errorf("expected ErrLimited, got %v", err)
+	}
+	release()
+	if _, err = server.acquire(); err != nil {
+		t.Errorf("permit should be available after release, got %v", err)
+	}
+}
+
+func TestServerLimiterIdleConn(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	server := &TcpRpcServer{
+		listen:         listener,
+		serviceMap:     map[string]any{"goods": &traceService{}},
+		Limiter:        limiter.NewConcurrency(1),
+		LimiterTimeout: 10 * time.Millisecond,
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tcpConn := &TcpConn{conn: conn, rpcChan: make(chan *CrRpcResponse, 1)}
+			go server.readHandle(tcpConn)
+			go server.writeHandle(tcpConn)
+		}
+	}()
+
+	// 尚未发送请求的连接不占用限流名额
+	idle, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewTcpClient(DefaultTcpClientOption)
+	client.conn = conn
+	defer client.Close()
+	rsp, err := client.Invoke(context.Background(), "goods", "Find", []any{int64(1001)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := rsp.(*CrRpcResponse).Data; data != "1001:" {
+		t.Errorf("request should not be limited by an idle connection, got %v", data)
+	}
+}
+
+type traceService struct{}
+
+func (s *traceService) Find(ctx context.Context, id int64) (string, error) {
+	return fmt.Sprintf("%d:%s", id, TraceIdFromContext(ctx)), nil
+}
+
+func TestTraceId(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	server := &TcpRpcServer{listen: listener, serviceMap: map[string]any{"goods": &traceService{}}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			tcpConn := &TcpConn{conn: conn, rpcChan: make(chan *CrRpcResponse, 1)}
+			go server.readHandle(tcpConn)
+			go server.writeHandle(tcpConn)
+		}
+	}()
+	invoke := func(ctx context.Context) any {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := NewTcpClient(DefaultTcpClientOption)
+		client.conn = conn
+		defer client.Close()
+		rsp, err := client.Invoke(ctx, "goods", "Find", []any{int64(1001)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rsp.(*CrRpcResponse).Data
+	}
+
+	if data := invoke(WithTraceId(context.Background(), "9f86d081")); data != "1001:9f86d081" {
+		t.Errorf("trace id should reach the service, got %v", data)
+	}
+	if data := invoke(context.Background()); data != "1001:" {
+		t.Errorf("unexpected result without trace id %v", data)
+	}
+}
+
+func TestDecodeMalformedFrame(t *testing.T) {
+	frame := func(version byte, fullLength uint32, rest ...byte) []byte {
+		header := make([]byte, 17)
+		header[0] = MagicNumber
+		header[1] = version
+		binary.BigEndian.PutUint32(header[2:6], fullLength)
+		header[6] = byte(msgRequest)
+		return append(header, rest...)
+	}
+	tests := map[string][]byte{
+		"short full length":  frame(Version, 10),
+		"trace id too long":  frame(VersionTrace, 20, 0, 10),
+		"trace id truncated": frame(VersionTrace, 18, 0, 0),
+		"unknown version":    frame(0x03, 17),
+	}
+	for name, data := range tests {
+		client, server := net.Pipe()
+		go func() {
+			_, _ = client.Write(data)
+			_ = client.Close()
+		}()
+		if _, err := decodeFrame(server); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		_ = server.Close()
+	}
+}
@@ -0,0 +1,137 @@
+package crpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// 发起请求并返回响应
+func serve(engine *Engine, method, path string) *httptest.ResponseRecorder {
+	writer := httptest.NewRecorder()
+	engine.ServeHTTP(writer, httptest.NewRequest(method, path, nil))
+	return writer
+}
+
+func TestGroupPrefix(t *testing.T) {
+	engine := MakeEngine()
+	engine.CreateGroup("user").Get("/x", func(ctx *Context) {
+		ctx.String(http.StatusOK, "user")
+	})
+	engine.CreateGroup("/api/v1/").Get("/x", func(ctx *Context) {
+		ctx.String(http.StatusOK, "v1")
+	})
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/user/x", http.StatusOK, "user"},
+		{"/api/v1/x", http.StatusOK, "v1"},
+		{"/api/user/x", http.StatusNotFound, ""},
+		{"/superuser/x", http.StatusNotFound, ""},
+		{"/api/x", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		writer := serve(engine, http.MethodGet, test.path)
+		if writer.Code != test.code {
+			t.Errorf("%s: expected %d, got %d", test.path, test.code, writer.Code)
+		}
+		if test.body != "" && writer.Body.String() != test.body {
+			t.Errorf("%s: expected %q, got %q", test.path, test.body, writer.Body.String())
+		}
+	}
+}
+
+func TestNestedGroup(t *testing.T) {
+	engine := MakeEngine()
+	mark := func(name string) MiddleWareFunc {
+		return func(next HandleFunc) HandleFunc {
+			return func(ctx *Context) {
+				ctx.Writer.Header().Add("X-Middleware", name)
+				next(ctx)
+			}
+		}
+	}
+	api := engine.CreateGroup("api")
+	v1 := api.Group("v1")
+	admin := v1.Group("/admin/")
+	admin.Get("/users", func(ctx *Context) {
+		ctx.String(http.StatusOK, "users")
+	})
+	// 子组创建之后添加的父组中间件同样生效
+	api.UseMiddleWare(mark("api"))
+	admin.UseMiddleWare(mark("admin"))
+
+	writer := serve(engine, http.MethodGet, "/api/v1/admin/users")
+	if writer.Code != http.StatusOK || writer.Body.String() != "users" {
+		t.Fatalf("unexpected response %d %q", writer.Code, writer.Body.String())
+	}
+	if got := writer.Header().Values("X-Middleware"); len(got) != 2 || got[0] != "api" || got[1] != "admin" {
+		t.Errorf("unexpected middleware order %v", got)
+	}
+	if writer := serve(engine, http.MethodGet, "/v1/admin/users"); writer.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", writer.Code)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	engine := MakeEngine()
+	group := engine.CreateGroup("user")
+	group.Get("/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "get")
+	})
+	group.Delete("/:id", func(ctx *Context) {
+		ctx.String(http.StatusOK, "delete")
+	})
+
+	writer := serve(engine, http.MethodPost, "/user/1")
+	if writer.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", writer.Code)
+	}
+	if allow := writer.Header().Get("Allow"); allow != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("unexpected Allow header %q", allow)
+	}
+
+	writer = serve(engine, http.MethodOptions, "/user/1")
+	if writer.Code != http.StatusNoContent || writer.Header().Get("Allow") != "DELETE, GET, HEAD, OPTIONS" {
+		t.Errorf("unexpected OPTIONS response %d %q", writer.Code, writer.Header().Get("Allow"))
+	}
+
+	writer = serve(engine, http.MethodHead, "/user/1")
+	if writer.Code != http.StatusOK || writer.Body.Len() != 0 {
+		t.Errorf("unexpected HEAD response %d %q", writer.Code, writer.Body.String())
+	}
+	if writer.Header().Get("Content-Type") == "" {
+		t.Error("HEAD response should keep the headers of GET")
+	}
+}
+
+func TestNoRoute(t *testing.T) {
+	engine := MakeEngine()
+	engine.UseMiddleWare(func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			ctx.Writer.Header().Set("X-Global", "true")
+			next(ctx)
+		}
+	})
+	engine.CreateGroup("user").Get("/info", func(ctx *Context) {})
+	engine.NoRoute(func(ctx *Context) {
+		ctx.JSON(http.StatusNotFound, map[string]any{"code": 404, "msg": "not found"})
+	})
+	engine.NoMethod(func(ctx *Context) {
+		ctx.JSON(http.StatusMethodNotAllowed, map[string]any{"code": 405, "msg": "not allowed"})
+	})
+
+	writer := serve(engine, http.MethodGet, "/order/info")
+	if writer.Code != http.StatusNotFound || writer.Body.String() != `{"code":404,"msg":"not found"}` {
+		t.Errorf("unexpected 404 response %d %q", writer.Code, writer.Body.String())
+	}
+	if writer.Header().Get("X-Global") != "true" {
+		t.Error("NoRoute handler should run through the global middlewares")
+	}
+	writer = serve(engine, http.MethodPost, "/user/info")
+	if writer.Code != http.StatusMethodNotAllowed || writer.Header().Get("X-Global") != "true" {
+		t.Errorf("unexpected 405 response %d %q", writer.Code, writer.Body.String())
+	}
+}
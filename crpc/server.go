@@ -0,0 +1,209 @@
+package crpc
+
+import (
+	"context"
+	"errors"
+	"github/CeerDecy/RpcFrameWork/crpc/register"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RunOptions http服务运行配置
+type RunOptions struct {
+	Addr              string        // 监听地址
+	CertFile          string        // 证书文件，与KeyFile同时设置时开启HTTPS
+	KeyFile           string        // 私钥文件
+	ReadTimeout       time.Duration // 读取整个请求的超时时间
+	ReadHeaderTimeout time.Duration // 读取请求头的超时时间
+	WriteTimeout      time.Duration // 写响应的超时时间
+	IdleTimeout       time.Duration // keep-alive连接的空闲超时时间
+	MaxHeaderBytes    int           // 请求头的最大字节数
+	ShutdownTimeout   time.Duration // 收到退出信号后等待请求处理完成的最长时间，默认10秒
+	Signals           []os.Signal   // 触发优雅退出的信号，默认为SIGINT和SIGTERM
+	ServiceName       string        // 不为空时将服务注册到注册中心，退出时最先注销
+	ServiceHost       string        // 注册到注册中心的地址，默认取监听地址，监听地址为空时为127.0.0.1
+}
+
+const defaultShutdownTimeout = 10 * time.Second
+
+// 注册到注册中心的服务实例
+type serviceInstance struct {
+	name string
+	host string
+	port uint64
+}
+
+// OnStart 添加启动钩子，在开始监听之后、处理请求之前按添加顺序执行
+func (e *Engine) OnStart(hook ...func()) {
+	e.onStart = append(e.onStart, hook...)
+}
+
+// OnShutdown 添加退出钩子，在请求处理完成后按添加顺序执行，可用于关闭数据库连接等资源
+func (e *Engine) OnShutdown(hook ...func(ctx context.Context)) {
+	e.onShutdown = append(e.onShutdown, hook...)
+}
+
+// RunWithOptions 使用独立的http.Server启动引擎，收到退出信号后优雅退出；
+// 正常退出时返回nil
+func (e *Engine) RunWithOptions(opts RunOptions) error {
+	client, err := register.CreateNacosClient()
+	if err != nil {
+		if opts.ServiceName != "" {
+			return err
+		}
+		if e.Logger != nil {
+			e.Logger.Debug("run register", err)
+		}
+	}
+	e.RegClient = client
+	listener, err := net.Listen("tcp", opts.Addr)
+	if err != nil {
+		return err
+	}
+	if opts.ServiceName != "" {
+		instance, err := makeServiceInstance(opts, listener.Addr())
+		if err == nil {
+			err = register.RegisService(e.RegClient, instance.name, instance.host, instance.port)
+		}
+		if err != nil {
+			_ = listener.Close()
+			return err
+		}
+		e.instance = instance
+	}
+	server := &http.Server{
+		Addr:              opts.Addr,
+		Handler:           e,
+		ReadTimeout:       opts.ReadTimeout,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+	}
+	done := make(chan struct{})
+	e.serverLock.Lock()
+	e.server = server
+	e.serverDone = done
+	e.started = true
+	shuttingDown := e.shuttingDown
+	e.shuttingDown = false
+	e.serverLock.Unlock()
+	if shuttingDown {
+		// 启动过程中已经调用了Shutdown，不再处理请求，直接退出
+		_ = listener.Close()
+		return e.Shutdown(context.Background())
+	}
+	e.debugPrintRoutes()
+	for _, hook := range e.onStart {
+		hook()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if opts.CertFile != "" && opts.KeyFile != "" {
+			serveErr <- server.ServeTLS(listener, opts.CertFile, opts.KeyFile)
+		} else {
+			serveErr <- server.Serve(listener)
+		}
+	}()
+
+	signals := opts.Signals
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, signals...)
+	defer signal.Stop(quit)
+
+	select {
+	case err = <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			_ = e.Shutdown(context.Background())
+			return err
+		}
+		// 由外部调用Shutdown触发，等待其执行完毕
+		<-done
+		return nil
+	case sig := <-quit:
+		if e.Logger != nil {
+			e.Logger.Info("Shutdown", "receive signal "+sig.String())
+		}
+	}
+	timeout := opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return e.Shutdown(ctx)
+}
+
+// Shutdown 优雅退出：先从注册中心注销，再等待处理中的请求完成，最后关闭连接并执行退出钩子；
+// ctx结束时仍未处理完的连接会被强制关闭；其他Shutdown正在执行时等待其完成或ctx结束；
+// 引擎从未启动过时，之后的RunWithOptions启动后立即退出
+func (e *Engine) Shutdown(ctx context.Context) error {
+	e.serverLock.Lock()
+	server, done := e.server, e.serverDone
+	e.server = nil
+	if !e.started {
+		e.shuttingDown = true
+	}
+	e.serverLock.Unlock()
+	if server == nil {
+		if done == nil {
+			return nil
+		}
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer func() {
+		e.serverLock.Lock()
+		e.serverDone = nil
+		e.serverLock.Unlock()
+		close(done)
+	}()
+
+	var deregisterErr error
+	if e.instance != nil {
+		deregisterErr = register.DeregisService(e.RegClient, e.instance.name, e.instance.host, e.instance.port)
+		e.instance = nil
+	}
+	err := server.Shutdown(ctx)
+	if err != nil {
+		_ = server.Close()
+	}
+	for _, hook := range e.onShutdown {
+		hook(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	return deregisterErr
+}
+
+// 根据配置和实际监听地址生成注册信息
+func makeServiceInstance(opts RunOptions, addr net.Addr) (*serviceInstance, error) {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil, err
+	}
+	if opts.ServiceHost != "" {
+		host = opts.ServiceHost
+	} else if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	p, err := strconv.ParseUint(port, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &serviceInstance{name: opts.ServiceName, host: host, port: p}, nil
+}
@@ -0,0 +1,198 @@
+package crpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 获取一个空闲的本地地址
+func freeAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestShutdown(t *testing.T) {
+	addr := freeAddr(t)
+	started := make(chan struct{})
+	handling := make(chan struct{})
+	var events []string
+	engine := MakeEngine()
+	engine.CreateGroup("user").Get("/slow", func(ctx *Context) {
+		close(handling)
+		time.Sleep(100 * time.Millisecond)
+		ctx.String(http.StatusOK, "done")
+	})
+	engine.OnStart(func() { close(started) })
+	engine.OnShutdown(func(ctx context.Context) { events = append(events, "shutdown") })
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- engine.RunWithOptions(RunOptions{Addr: addr, ReadHeaderTimeout: time.Second})
+	}()
+	<-started
+
+	body := make(chan string, 1)
+	go func() {
+		rsp, err := http.Get("http://" + addr + "/user/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer rsp.Body.Close()
+		data, _ := io.ReadAll(rsp.Body)
+		body <- string(data)
+	}()
+	<-handling
+	if err := engine.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request should be drained, got %q", got)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunWithOptions returned %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("OnShutdown hooks should run once, got %v", events)
+	}
+	if _, err := http.Get("http://" + addr + "/user/slow"); err == nil {
+		t.Error("server should not accept requests after shutdown")
+	}
+}
+
+func TestShutdownBeforeRun(t *testing.T) {
+	addr := freeAddr(t)
+	started := false
+	shutdown := 0
+	engine := MakeEngine()
+	engine.OnStart(func() { started = true })
+	engine.OnShutdown(func(ctx context.Context) { shutdown++ })
+	if err := engine.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- engine.RunWithOptions(RunOptions{Addr: addr})
+	}()
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("RunWithOptions returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shutdown requested during startup was ignored")
+	}
+	if started || shutdown != 1 {
+		t.Errorf("unexpected hooks: started %v, shutdown %d", started, shutdown)
+	}
+	if _, err := http.Get("http://" + addr + "/"); err == nil {
+		t.Error("server should not accept requests after shutdown")
+	}
+}
+
+func TestShutdownConcurrent(t *testing.T) {
+	started := make(chan struct{}, 2)
+	handling := make(chan struct{})
+	var hooked atomic.Bool
+	engine := MakeEngine()
+	engine.CreateGroup("user").Get("/slow", func(ctx *Context) {
+		close(handling)
+		time.Sleep(100 * time.Millisecond)
+	})
+	engine.OnStart(func() { started <- struct{}{} })
+	engine.OnShutdown(func(ctx context.Context) { hooked.Store(true) })
+
+	addr := freeAddr(t)
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- engine.RunWithOptions(RunOptions{Addr: addr})
+	}()
+	<-started
+	go func() {
+		if rsp, err := http.Get("http://" + addr + "/user/slow"); err == nil {
+			rsp.Body.Close()
+		}
+	}()
+	<-handling
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			err := engine.Shutdown(context.Background())
+			if err == nil && !hooked.Load() {
+				err = errors.New("Shutdown returned before draining finished")
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunWithOptions returned %v", err)
+	}
+
+	// 已经退出后再调用Shutdown不影响下一次启动
+	if err := engine.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		runErr <- engine.RunWithOptions(RunOptions{Addr: freeAddr(t)})
+	}()
+	select {
+	case <-started:
+	case err := <-runErr:
+		t.Fatalf("engine should start again, RunWithOptions returned %v", err)
+	}
+	if err := engine.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-runErr; err != nil {
+		t.Errorf("RunWithOptions returned %v", err)
+	}
+}
+
+func TestShutdownOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending signals is not supported on windows")
+	}
+	started := make(chan struct{})
+	shutdown := make(chan struct{})
+	engine := MakeEngine()
+	engine.OnStart(func() { close(started) })
+	engine.OnShutdown(func(ctx context.Context) { close(shutdown) })
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- engine.RunWithOptions(RunOptions{Addr: "127.0.0.1:0", Signals: []os.Signal{os.Interrupt}})
+	}()
+	<-started
+	process, _ := os.FindProcess(os.Getpid())
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("RunWithOptions returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("engine did not shut down on signal")
+	}
+	<-shutdown
+}
@@ -0,0 +1,128 @@
+package crpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout 超时中间件，为请求上下文设置截止时间，处理函数在独立的协程中执行；
+// 超时后由onTimeout写出响应（为nil时返回504），仍在执行的处理函数之后的写入会被丢弃。
+// 响应在处理函数返回前缓存在内存中，Flush不会把数据发送给客户端，不适用于流式响应
+func Timeout(timeout time.Duration, onTimeout HandleFunc) MiddleWareFunc {
+	if onTimeout == nil {
+		onTimeout = defaultTimeoutHandle
+	}
+	return func(next HandleFunc) HandleFunc {
+		return func(ctx *Context) {
+			reqCtx, cancel := context.WithTimeout(ctx.Request.Context(), timeout)
+			defer cancel()
+			// 处理函数使用独立的上下文，超时返回后ctx被放回Pool也不会受影响
+			// 处理函数可以读取之前的中间件设置的响应头
+			tw := &timeoutWriter{header: ctx.Writer.Header().Clone(), status: http.StatusOK}
+			handleCtx := ctx.Copy()
+			handleCtx.Request = ctx.Request.WithContext(reqCtx)
+			handleCtx.writermem.reset(tw)
+			handleCtx.Writer = &handleCtx.writermem
+
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+			go func() {
+				defer func() {
+					if err := recover(); err != nil {
+						panicChan <- err
+					}
+				}()
+				next(handleCtx)
+				close(done)
+			}()
+
+			select {
+			case err := <-panicChan:
+				// 在请求协程中重新panic，交给Recovery处理
+				panic(err)
+			case <-done:
+				tw.writeTo(ctx.Writer)
+				ctx.aborted = handleCtx.aborted
+				for key, value := range handleCtx.Keys {
+					ctx.Set(key, value)
+				}
+			case <-reqCtx.Done():
+				tw.timeout()
+				ctx.timedOut = true
+				ctx.Abort()
+				if reqCtx.Err() == context.DeadlineExceeded {
+					onTimeout(ctx)
+				}
+			}
+		}
+	}
+}
+
+func defaultTimeoutHandle(ctx *Context) {
+	ctx.String(http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout))
+}
+
+// 超时中间件中处理函数使用的ResponseWriter，在处理函数完成前缓存响应，超时后丢弃写入
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.wroteHeader = true
+	return w.buf.Write(data)
+}
+
+func (w *timeoutWriter) timeout() {
+	w.mu.Lock()
+	w.timedOut = true
+	w.mu.Unlock()
+}
+
+// 处理函数按时完成后将缓存的响应写出
+func (w *timeoutWriter) writeTo(writer ResponseWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// w.header由原响应头复制而来，处理函数删除的响应头也要删除
+	header := writer.Header()
+	for key := range header {
+		if _, ok := w.header[key]; !ok {
+			delete(header, key)
+		}
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+	if w.wroteHeader {
+		writer.WriteHeader(w.status)
+	}
+	if w.buf.Len() > 0 {
+		_, _ = writer.Write(w.buf.Bytes())
+	}
+}
@@ -0,0 +1,70 @@
+package crpc
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	var out bytes.Buffer
+	cancelled := make(chan struct{})
+	engine := MakeEngine()
+	engine.UseMiddleWare(func(next HandleFunc) HandleFunc {
+		return LoggingWithConfig(LoggingConfig{out: &out}, next)
+	}, WrapHandle(func(ctx *Context) {
+		ctx.Writer.Header().Set("X-Before", "1")
+	}))
+	group := engine.CreateGroup("user")
+	group.UseMiddleWare(Timeout(20*time.Millisecond, nil))
+	group.Get("/slow", func(ctx *Context) {
+		<-ctx.Done()
+		close(cancelled)
+		ctx.String(http.StatusOK, "too late")
+	})
+	group.Get("/fast", func(ctx *Context) {
+		// 可以读取之前的中间件设置的响应头
+		ctx.Writer.Header().Set("X-Handler", "fast"+ctx.Writer.Header().Get("X-Before"))
+		ctx.Writer.Header().Del("X-Before")
+		ctx.String(http.StatusCreated, "ok")
+	})
+
+	writer := serve(engine, http.MethodGet, "/user/slow")
+	if writer.Code != http.StatusGatewayTimeout || writer.Body.String() != "Gateway Timeout" {
+		t.Errorf("unexpected response %d %q", writer.Code, writer.Body.String())
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("handler context should be cancelled on timeout")
+	}
+	if !strings.Contains(out.String(), " 504 ") || !strings.Contains(out.String(), "timeout") {
+		t.Errorf("access log should record the timeout: %q", out.String())
+	}
+
+	out.Reset()
+	writer = serve(engine, http.MethodGet, "/user/fast")
+	if writer.Code != http.StatusCreated || writer.Body.String() != "ok" || writer.Header().Get("X-Handler") != "fast1" ||
+		writer.Header().Get("X-Before") != "" {
+		t.Errorf("unexpected response %d %q %v", writer.Code, writer.Body.String(), writer.Header())
+	}
+	if strings.Contains(out.String(), "timeout") {
+		t.Errorf("fast request should not be logged as timeout: %q", out.String())
+	}
+}
+
+func TestTimeoutHandle(t *testing.T) {
+	engine := MakeEngine()
+	group := engine.CreateGroup("user")
+	group.Get("/slow", func(ctx *Context) {
+		time.Sleep(50 * time.Millisecond)
+	}, Timeout(10*time.Millisecond, func(ctx *Context) {
+		ctx.Fail(http.StatusServiceUnavailable, "busy")
+	}))
+	writer := serve(engine, http.MethodGet, "/user/slow")
+	if writer.Code != http.StatusServiceUnavailable || !strings.Contains(writer.Body.String(), "busy") {
+		t.Errorf("unexpected response %d %q", writer.Code, writer.Body.String())
+	}
+}
@@ -1,74 +1,260 @@
 package crpc
 
 import (
+	"fmt"
 	"strings"
 )
 
-type treeNode struct {
-	name      string
-	child     []*treeNode
-	routePath string // 完整路径
-	isEnd     bool   // 是否为伪节点
+// 节点类型，数值越小匹配优先级越高
+type nodeType uint8
+
+const (
+	staticNode   nodeType = iota // 静态路径 /user
+	paramNode                    // 命名参数 /:id
+	wildcardNode                 // 单段通配符 /*
+	catchAllNode                 // 全匹配通配符 /**
+)
+
+// 根据路径片段判断节点类型
+func segmentType(segment string) nodeType {
+	switch {
+	case segment == "**":
+		return catchAllNode
+	case segment == "*":
+		return wildcardNode
+	case strings.HasPrefix(segment, ":"):
+		return paramNode
+	default:
+		return staticNode
+	}
+}
+
+// 压缩前缀树（radix tree）节点
+// 静态节点保存与兄弟节点不重复的最长公共前缀，通配节点独占一个路径段
+type node struct {
+	path       string // 静态节点为压缩后的路径片段，通配节点为 :name、* 或 **
+	nType      nodeType
+	indices    string  // 静态子节点的首字符，与children一一对应
+	children   []*node // 静态子节点
+	paramChild *node
+	wildChild  *node
+	catchChild *node
+	fullPath   string // 通配节点所在的完整路径，用于提示冲突
+	route      *route // 不为空说明有路由在此结束
+}
+
+// 规范化路由：以/开头，去除末尾的/
+func cleanPath(path string) string {
+	return "/" + strings.Trim(path, "/")
+}
+
+// 拼接路由前缀与相对路由，如 api/v1 与 /user 拼接为 /api/v1/user
+func joinPaths(prefix, relative string) string {
+	return cleanPath(strings.Trim(prefix, "/") + "/" + strings.Trim(relative, "/"))
 }
 
-// 以递归的方式添加path
-func putDfs(splits []string, route string, index int, root *treeNode) {
-	// 若index超过splits说明所有节点都已经添加完毕
-	if index >= len(splits) {
-		return
+// 校验路由片段，非法或存在歧义的路由直接panic
+func checkSegments(segments []string, path string) {
+	for i, segment := range segments {
+		if segment == ":" {
+			panic(fmt.Sprintf("route '%s': wildcard ':' must be named", path))
+		}
+		if strings.Contains(segment, ":") && !strings.HasPrefix(segment, ":") {
+			panic(fmt.Sprintf("route '%s': wildcard '%s' must occupy a whole path segment", path, segment))
+		}
+		if segment == "**" && i != len(segments)-1 {
+			panic(fmt.Sprintf("route '%s': catch-all '**' must be the last path segment", path))
+		}
 	}
-	for _, node := range root.child {
-		if node.name == splits[index] {
-			route += "/" + splits[index]
-			putDfs(splits, route, index+1, node) // 递归查找已有的路由
-			return
+}
+
+// 统计路由中会产生路径参数的片段数量
+func countParams(path string) int {
+	n := 0
+	for _, segment := range strings.Split(path, "/") {
+		if t := segmentType(segment); t == paramNode || t == catchAllNode {
+			n++
+		}
+	}
+	return n
+}
+
+// 计算两个字符串的最长公共前缀长度
+func longestCommonPrefix(a, b string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// 添加路由，静态部分按公共前缀压缩，通配部分挂在所在路径段的父节点上
+func (n *node) addRoute(path string, r *route) {
+	path = cleanPath(path)
+	segments := strings.Split(path[1:], "/")
+	checkSegments(segments, path)
+	current := n
+	static := ""
+	for i, segment := range segments {
+		nType := segmentType(segment)
+		if nType == staticNode {
+			static += "/" + segment
+			continue
+		}
+		current = current.insertStatic(static + "/")
+		static = ""
+		current = current.insertWildcard(segment, nType, "/"+strings.Join(segments[:i+1], "/"))
+	}
+	current = current.insertStatic(static)
+	if current.route != nil {
+		panic(fmt.Sprintf("route '%s' has already been registered", path))
+	}
+	current.route = r
+}
+
+// 插入静态路径，必要时拆分已有节点，返回路径末尾对应的节点
+func (n *node) insertStatic(path string) *node {
+	for path != "" {
+		index := strings.IndexByte(n.indices, path[0])
+		if index < 0 {
+			child := &node{path: path}
+			n.indices += path[:1]
+			n.children = append(n.children, child)
+			return child
 		}
+		child := n.children[index]
+		l := longestCommonPrefix(path, child.path)
+		if l < len(child.path) {
+			// 拆分节点：原节点保留公共前缀，剩余部分连同子节点和路由下沉
+			tail := *child
+			tail.path = child.path[l:]
+			*child = node{
+				path:     child.path[:l],
+				indices:  tail.path[:1],
+				children: []*node{&tail},
+			}
+		}
+		n = child
+		path = path[l:]
 	}
-	addNode := &treeNode{name: splits[index]}
-	route += "/" + splits[index]
-	addNode.routePath = route
-	if index == len(splits)-1 {
-		addNode.isEnd = true
+	return n
+}
+
+// 插入通配节点，同一位置的参数名必须一致
+func (n *node) insertWildcard(segment string, nType nodeType, fullPath string) *node {
+	var child **node
+	switch nType {
+	case paramNode:
+		child = &n.paramChild
+	case wildcardNode:
+		child = &n.wildChild
+	default:
+		child = &n.catchChild
 	}
-	putDfs(splits, route, index+1, addNode)
-	root.child = append(root.child, addNode)
+	if *child == nil {
+		*child = &node{path: segment, nType: nType, fullPath: fullPath}
+	} else if (*child).path != segment {
+		panic(fmt.Sprintf("route '%s': wildcard '%s' conflicts with existing wildcard '%s' in '%s'",
+			fullPath, segment, (*child).path, (*child).fullPath))
+	}
+	return *child
 }
 
-// Put 添加路径
-func (t *treeNode) Put(path string) {
-	path = strings.Trim(path, "/")
-	splits := strings.Split(path, "/")
-	// 若i == 0第一个字符可能是空格，因此需要忽略
-	if len(splits) >= 1 {
-		putDfs(splits, "", 0, t)
+// 查找与path匹配的节点，匹配到的路径参数追加到params中
+// 末尾带/的请求在匹配失败后会去掉/重新匹配
+func (n *node) getValue(path string, params *Params) *node {
+	if found := n.match(path, params); found != nil {
+		return found
+	}
+	if len(path) > 1 && path[len(path)-1] == '/' {
+		return n.match(path[:len(path)-1], params)
 	}
+	return nil
 }
 
-// 以递归的方式查找路径节点
-func getDfs(splits []string, index int, root *treeNode) (*treeNode, bool) {
-	if index >= len(splits) {
-		return nil, false
+// 以递归的方式匹配剩余路径
+// 子节点按 静态 > 参数 > 单段通配 > 全匹配 的顺序尝试，深层匹配失败时回溯到下一个候选节点
+func (n *node) match(path string, params *Params) *node {
+	if path == "" {
+		if n.route != nil {
+			return n
+		}
+		return nil
 	}
-	for _, node := range root.child {
-		if node.name == "**" {
-			return node, true
+	if index := strings.IndexByte(n.indices, path[0]); index >= 0 {
+		child := n.children[index]
+		if len(path) >= len(child.path) && path[:len(child.path)] == child.path {
+			if found := child.match(path[len(child.path):], params); found != nil {
+				return found
+			}
 		}
-		if node.name == splits[index] ||
-			node.name == "*" ||
-			strings.Contains(node.name, ":") {
-			if index+1 == len(splits) {
-				return node, true
+	}
+	// 通配节点只挂在以/结尾的节点上，因此此处path必然位于路径段开头
+	end := strings.IndexByte(path, '/')
+	if end < 0 {
+		end = len(path)
+	}
+	if end > 0 {
+		if child := n.paramChild; child != nil {
+			mark := len(*params)
+			*params = append(*params, Param{Key: child.path[1:], Value: path[:end]})
+			if found := child.match(path[end:], params); found != nil {
+				return found
 			}
-			return getDfs(splits, index+1, node)
+			*params = (*params)[:mark]
+		}
+		if child := n.wildChild; child != nil {
+			if found := child.match(path[end:], params); found != nil {
+				return found
+			}
+		}
+	}
+	if child := n.catchChild; child != nil {
+		*params = append(*params, Param{Key: child.path, Value: path})
+		return child
+	}
+	return nil
+}
+
+// 每个HTTP方法对应一棵路由树
+type methodTree struct {
+	method string
+	root   *node
+}
+
+type methodTrees []methodTree
+
+// 获取方法对应的路由树，方法数量很少，线性查找比map更快
+func (trees methodTrees) get(method string) *node {
+	for _, tree := range trees {
+		if tree.method == method {
+			return tree.root
+		}
+	}
+	return nil
+}
+
+// Param 路径参数，如 /user/:id 中的id
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 按匹配顺序排列的路径参数
+type Params []Param
+
+// Get 根据参数名获取参数值
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
 		}
 	}
-	return nil, false
+	return "", false
 }
 
-// Get 通过path获取节点
-func (t *treeNode) Get(path string) *treeNode {
-	path = strings.Trim(path, "/")
-	splits := strings.Split(path, "/")
-	node, _ := getDfs(splits, 0, t)
-	return node
+// ByName 根据参数名获取参数值，不存在时返回空字符串
+func (ps Params) ByName(name string) string {
+	value, _ := ps.Get(name)
+	return value
 }
@@ -2,23 +2,150 @@ package crpc
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
+// 构造只包含给定路由的路由树
+func newTestTree(paths ...string) *node {
+	root := &node{}
+	for _, path := range paths {
+		root.addRoute(path, &route{relative: path})
+	}
+	return root
+}
+
+// 查找路由，返回匹配到的路由路径
+func lookup(root *node, path string) (string, Params) {
+	params := make(Params, 0)
+	n := root.getValue(path, &params)
+	if n == nil {
+		return "", params
+	}
+	return n.route.relative, params
+}
+
 func TestName(t *testing.T) {
-	//fmt.Println()
-	root := &treeNode{name: "/user"}
-	//root.Put("/abc/test")
-	//root.Put("/abc/ttt")
-	//root.Put("/def/**")
-	//root.Put("/gh/:id")
-	//root.Put("/html")
-	root.Put("/imageByName")
-	fmt.Println(root.Get("/imageByName"))
-	//fmt.Println(root.Get("/abc/ttt"))
-	//fmt.Println(root.Get("/abc/sss"))
-	//fmt.Println(root.Get("/def/3443/fudn"))
-	//fmt.Println(root.Get("/def/3443"))
-	//fmt.Println(root.Get("/gh/3443"))
-	//fmt.Println(root.Get("/html"))
+	root := newTestTree("/user/imageByName", "/user/image", "/user/info")
+	for _, path := range []string{"/user/imageByName", "/user/image", "/user/info", "/user/info/"} {
+		if route, _ := lookup(root, path); route != cleanPath(path) {
+			t.Errorf("%s: got %q", path, route)
+		}
+	}
+	if route, _ := lookup(root, "/user/im"); route != "" {
+		t.Errorf("/user/im: unexpected route %q", route)
+	}
+}
+
+func TestParams(t *testing.T) {
+	root := newTestTree("/user/info/:id/:name", "/user/static/**")
+	route, params := lookup(root, "/user/info/1001/ceer")
+	if route != "/user/info/:id/:name" {
+		t.Fatalf("route not matched: %q", route)
+	}
+	if params.ByName("id") != "1001" || params.ByName("name") != "ceer" {
+		t.Errorf("unexpected params %v", params)
+	}
+	_, params = lookup(root, "/user/static/css/main.css")
+	if params.ByName("**") != "css/main.css" {
+		t.Errorf("unexpected params %v", params)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	root := newTestTree(
+		"/user/**",
+		"/user/*/profile",
+		"/user/:id",
+		"/user/me",
+		"/user/:id/orders",
+		"/user/me/settings/detail",
+		"/user/mine",
+	)
+	tests := []struct {
+		path  string
+		route string
+	}{
+		{"/user/me", "/user/me"},
+		{"/user/mine", "/user/mine"},
+		{"/user/1001", "/user/:id"},
+		{"/user/mi", "/user/:id"},
+		{"/user/1001/orders", "/user/:id/orders"},
+		{"/user/me/orders", "/user/:id/orders"},
+		{"/user/1001/profile", "/user/*/profile"},
+		{"/user/me/settings", "/user/**"},
+		{"/user/1001/orders/2", "/user/**"},
+	}
+	for _, test := range tests {
+		if route, _ := lookup(root, test.path); route != test.route {
+			t.Errorf("%s: expected %s, got %q", test.path, test.route, route)
+		}
+	}
+}
+
+func TestConflict(t *testing.T) {
+	tests := [][]string{
+		{"/user/:id", "/user/:name"},
+		{"/user/**/detail"},
+		{"/user/user:id"},
+		{"/user/:"},
+		{"/user/info", "/user/info/"},
+	}
+	for _, routes := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v: expected panic", routes)
+				}
+			}()
+			newTestTree(routes...)
+		}()
+	}
+}
+
+func TestLookupAllocs(t *testing.T) {
+	root := newTestTree("/user/info/:id/:name", "/user/static/**", "/user/list")
+	params := make(Params, 0, 2)
+	allocs := testing.AllocsPerRun(100, func() {
+		params = params[:0]
+		root.getValue("/user/info/1001/ceer", &params)
+		params = params[:0]
+		root.getValue("/user/list", &params)
+	})
+	if allocs != 0 {
+		t.Errorf("expected zero allocations, got %v", allocs)
+	}
+}
+
+// 模拟网关规模的路由表：8个组，每组60个路由
+func benchEngine() *Engine {
+	engine := DefaultEngine()
+	for _, name := range []string{"user", "goods", "order", "pay", "stock", "cart", "coupon", "address"} {
+		group := engine.CreateGroup(name)
+		for i := 0; i < 30; i++ {
+			group.Get(fmt.Sprintf("/static%d/list", i), func(ctx *Context) {})
+			group.Get(fmt.Sprintf("/item%d/:id", i), func(ctx *Context) {})
+		}
+	}
+	return engine
+}
+
+func benchRequest(b *testing.B, path string) {
+	engine := benchEngine()
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	writer := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(writer, request)
+	}
+}
+
+func BenchmarkStaticRoute(b *testing.B) {
+	benchRequest(b, "/address/static29/list")
+}
+
+func BenchmarkParamRoute(b *testing.B) {
+	benchRequest(b, "/address/item29/1001")
 }
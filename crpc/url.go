@@ -0,0 +1,80 @@
+package crpc
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Name 设置路由名称，之后可以通过 Engine.URLFor 根据名称生成URL
+func (r *route) Name(name string) Route {
+	engine := r.group.engine
+	if exist, ok := engine.namedRoutes[name]; ok && exist != r {
+		panic(fmt.Sprintf("route name '%s' has already been used by %s %s", name, exist.method, exist.path))
+	}
+	if r.name != "" {
+		delete(engine.namedRoutes, r.name)
+	}
+	r.name = name
+	engine.namedRoutes[name] = r
+	return r
+}
+
+// URLFor 根据路由名称生成完整路径
+// params为键值对，如 URLFor("user.info", "id", 1001)，:id 与 ** 由对应的键替换，
+// 路由中未用到的键值对会作为查询参数拼接在路径后
+func (e *Engine) URLFor(name string, params ...any) (string, error) {
+	r, ok := e.namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("route named '%s' not found", name)
+	}
+	if len(params)%2 != 0 {
+		return "", fmt.Errorf("route '%s': params must be key-value pairs", name)
+	}
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		key, ok := params[i].(string)
+		if !ok {
+			return "", fmt.Errorf("route '%s': param key %v is not a string", name, params[i])
+		}
+		values[key] = fmt.Sprint(params[i+1])
+	}
+	var builder strings.Builder
+	for _, segment := range strings.Split(r.path[1:], "/") {
+		builder.WriteByte('/')
+		switch segmentType(segment) {
+		case paramNode:
+			value, ok := values[segment[1:]]
+			if !ok {
+				return "", fmt.Errorf("route '%s': missing param '%s'", name, segment[1:])
+			}
+			delete(values, segment[1:])
+			builder.WriteString(url.PathEscape(value))
+		case catchAllNode:
+			value, ok := values[segment]
+			if !ok {
+				return "", fmt.Errorf("route '%s': missing param '%s'", name, segment)
+			}
+			delete(values, segment)
+			for i, part := range strings.Split(strings.TrimPrefix(value, "/"), "/") {
+				if i > 0 {
+					builder.WriteByte('/')
+				}
+				builder.WriteString(url.PathEscape(part))
+			}
+		case wildcardNode:
+			return "", fmt.Errorf("route '%s': anonymous wildcard '*' cannot be reversed", name)
+		default:
+			builder.WriteString(segment)
+		}
+	}
+	if len(values) > 0 {
+		query := make(url.Values, len(values))
+		for key, value := range values {
+			query.Set(key, value)
+		}
+		builder.WriteByte('?')
+		builder.WriteString(query.Encode())
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,60 @@
+package crpc
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"testing"
+)
+
+func TestURLFor(t *testing.T) {
+	engine := MakeEngine()
+	v1 := engine.CreateGroup("api").Group("v1")
+	v1.Get("/user/:id", func(ctx *Context) {}).Name("user.info")
+	v1.Get("/static/**", func(ctx *Context) {}).Name("static")
+	v1.Get("/", func(ctx *Context) {}).Name("index")
+	v1.Get("/user/*/avatar", func(ctx *Context) {}).Name("avatar")
+
+	tests := []struct {
+		name   string
+		params []any
+		url    string
+	}{
+		{"user.info", []any{"id", 1001}, "/api/v1/user/1001"},
+		{"user.info", []any{"id", "a b", "tab", "orders"}, "/api/v1/user/a%20b?tab=orders"},
+		{"static", []any{"**", "css/main.css"}, "/api/v1/static/css/main.css"},
+		{"index", nil, "/api/v1"},
+	}
+	for _, test := range tests {
+		url, err := engine.URLFor(test.name, test.params...)
+		if err != nil || url != test.url {
+			t.Errorf("%s %v: expected %s, got %s %v", test.name, test.params, test.url, url, err)
+		}
+	}
+	for _, params := range [][]any{{"user.info"}, {"user.info", "name", "ceer"}, {"user.info", "id"}, {"avatar"}, {"unknown"}} {
+		if _, err := engine.URLFor(params[0].(string), params[1:]...); err == nil {
+			t.Errorf("%v: expected error", params)
+		}
+	}
+
+	tmpl := template.Must(template.New("").Funcs(engine.funcMap).Parse(`{{ urlFor "user.info" "id" . }}`))
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, 7); err != nil || buf.String() != "/api/v1/user/7" {
+		t.Errorf("unexpected template output %q %v", buf.String(), err)
+	}
+}
+
+func TestRedirectToRoute(t *testing.T) {
+	engine := MakeEngine()
+	group := engine.CreateGroup("user")
+	group.Get("/login", func(ctx *Context) {}).Name("login")
+	group.Get("/logout", func(ctx *Context) {
+		if err := ctx.RedirectToRoute(http.StatusFound, "login", "from", "logout"); err != nil {
+			t.Error(err)
+		}
+	})
+	writer := serve(engine, http.MethodGet, "/user/logout")
+	if writer.Code != http.StatusFound || writer.Header().Get("Location") != "/user/login?from=logout" {
+		t.Errorf("unexpected redirect %d %q", writer.Code, writer.Header().Get("Location"))
+	}
+}
@@ -9,6 +9,7 @@ import (
 func main() {
 	engine := crpc.DefaultEngine()
 	engine.OpenGateway = true
+	engine.UseMiddleWare(crpc.RequestID)
 	engine.SetGatewayConfig([]*gateway.GWConfig{
 		{
 			Name:        "order",
@@ -9,6 +9,9 @@ import (
 
 func main() {
 	engine := crpc.DefaultEngine()
+	engine.UseMiddleWare(crpc.RequestID)
+	// 请求经本机的网关转发，从转发请求头中获取客户端IP
+	_ = engine.SetTrustedProxies([]string{"127.0.0.1", "::1"})
 	gob.Register(&model.Response{})
 	gob.Register(&model.Goods{})
 	//engine.UseMiddleWare(crpc.Limiter(1, 1))
@@ -1,7 +1,6 @@
 package order
 
 import (
-	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -35,15 +34,18 @@ func Find(ctx *crpc.Context) {
 }
 
 func FindGrpc(ctx *crpc.Context) {
-	client, err := rpc.NewGrpcClient(rpc.DefaultGrpcClientConfig("127.0.0.1:9000"))
+	client, err := rpc.NewGrpcClientContext(ctx, rpc.DefaultGrpcClientConfig("127.0.0.1:9000"))
 	if err != nil {
 		ctx.Logger.Error("FindGrpc", err.Error())
 		ctx.JSON(http.StatusOK, model.SuccessResponse(err.Error()))
+		return
 	}
 	defer client.Conn.Close()
 	client.Conn.Connect()
 	goodsApiClient := api.NewGoodsApiClient(client.Conn)
-	goodsResponse, _ := goodsApiClient.Find(context.Background(), &api.GoodsRequest{})
+	callCtx, cancel := client.Context(ctx)
+	defer cancel()
+	goodsResponse, _ := goodsApiClient.Find(callCtx, &api.GoodsRequest{})
 	ctx.JSON(http.StatusOK, goodsResponse)
 }
 
@@ -62,7 +64,7 @@ func FindTcp(ctx *crpc.Context) {
 		}
 		fmt.Println(ctx.GetHeader("Hello"))
 		proxy := rpc.NewTcpClientProxy(rpc.DefaultTcpClientOption.Protobuf())
-		result, err := proxy.Call(context.Background(), "goods", "Find", []any{int64(1001)})
+		result, err := proxy.Call(ctx, "goods", "Find", []any{int64(1001)})
 		return result, err
 	})
 	if err != nil {
@@ -78,7 +80,7 @@ type RpcServiceOrder struct {
 
 func (r *RpcServiceOrder) FindRpc(ctx *crpc.Context) {
 	//proxy := rpc.NewTcpClientProxy(rpc.DefaultTcpClientOption.Protobuf())
-	//result, err := proxy.Call(context.Background(), "goods", "Find", []any{int64(1001)})
+	//result, err := proxy.Call(ctx, "goods", "Find", []any{int64(1001)})
 	//if err != nil {
 	//	ctx.Logger.Error("FindGrpc", err.Error())
 	//	ctx.JSON(http.StatusOK, model.SuccessResponse(err.Error()))
@@ -70,7 +70,7 @@ func main() {
 		//	"static/html/login.html", "static/html/header.html")
 		ctx.HTMLTemplateGlob("login.html", user,
 			"static/html/*.html")
-	})
+	}).Name("user.login")
 	// Register模板
 	group.Get("/html/register", func(ctx *crpc.Context) {
 		user := &models.User{Name: "猛喝威士忌"}
@@ -107,7 +107,9 @@ func main() {
 	// 重定向
 	group.Get("/redirect", func(ctx *crpc.Context) {
 		// 重定向的状态值为302
-		ctx.Redirect(http.StatusFound, "/user/html/login")
+		if err := ctx.RedirectToRoute(http.StatusFound, "user.login"); err != nil {
+			ctx.HandleWithError(err)
+		}
 	})
 
 	// 格式化字符串